@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	"jamesfarrell.me/youtube-to-text/internal/config"
+	"jamesfarrell.me/youtube-to-text/internal/ingest"
+	"jamesfarrell.me/youtube-to-text/internal/storage/db"
+	"jamesfarrell.me/youtube-to-text/internal/storage/postgres"
+)
+
+const usage = `usage: ingest <db-identifier> playlist <url> [--searchable]
+       ingest <db-identifier> feed <url> [--searchable]
+
+Imports every video of a YouTube playlist or channel, or every episode of a
+podcast RSS or Atom feed.`
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Error loading .env file: %v\n", err)
+	}
+
+	if len(os.Args) < 4 {
+		log.Fatal(usage)
+	}
+	command, url := os.Args[2], os.Args[3]
+	searchable := len(os.Args) > 4 && os.Args[4] == "--searchable"
+
+	dbURL := config.GetDatabaseURL()
+
+	// Initialize database connection
+	database, err := db.NewConnection(db.Config{URL: dbURL})
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer database.Close()
+
+	collectionRepo := postgres.NewCollectionRepository(database)
+	ctx := context.Background()
+
+	switch command {
+	case "playlist":
+		response, err := ingest.ImportPlaylist(ctx, collectionRepo, url, searchable)
+		if err != nil {
+			log.Fatalf("Failed to import playlist: %v", err)
+		}
+		fmt.Printf("Imported %s %q (%s): %d added, %d already present\n",
+			response.Kind, response.Title, response.ID, response.Added, response.Skipped)
+	case "feed":
+		response, err := ingest.ImportFeed(ctx, collectionRepo, url, searchable)
+		if err != nil {
+			log.Fatalf("Failed to import feed: %v", err)
+		}
+		fmt.Printf("Imported feed %q (%s): %d added, %d already present\n",
+			response.Title, response.ID, response.Added, response.Skipped)
+	default:
+		log.Fatal(usage)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"jamesfarrell.me/youtube-to-text/internal/config"
+	"jamesfarrell.me/youtube-to-text/internal/embeddings"
+	"jamesfarrell.me/youtube-to-text/internal/storage/db"
+	"jamesfarrell.me/youtube-to-text/internal/storage/postgres"
+	"jamesfarrell.me/youtube-to-text/internal/transcription"
+)
+
+const usage = `usage: reindex <db-identifier> [flags]
+
+Re-embeds search chunks with the configured embedding model (EMBEDDING_*),
+next to the vectors already stored. Videos that already have vectors from the
+model are skipped unless -all is given. Once the service runs with the new
+model, -prune removes the old vectors.
+
+Flags:`
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Error loading .env file: %v\n", err)
+	}
+
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	flags := flag.NewFlagSet("reindex", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	cfg := config.GetEmbeddingConfig()
+	flags.StringVar(&cfg.Provider, "provider", cfg.Provider, "embedding provider: openai, openai-compatible or hash")
+	flags.StringVar(&cfg.BaseURL, "base-url", cfg.BaseURL, "base URL of an openai-compatible server")
+	flags.StringVar(&cfg.Model, "model", cfg.Model, "embedding model")
+	flags.IntVar(&cfg.Dimensions, "dimensions", cfg.Dimensions, "vector size for models that support shortening")
+	videos := flags.String("video", "", "comma-separated video IDs to reindex instead of every video")
+	all := flags.Bool("all", false, "re-embed videos that already have vectors from the model")
+	prune := flags.Bool("prune", false, "delete vectors from other models for videos indexed with this one, then exit")
+	status := flags.Bool("status", false, "list the stored embedding models, then exit")
+	flags.Parse(os.Args[2:])
+
+	dbURL := config.GetDatabaseURL()
+
+	// Initialize database connection
+	database, err := db.NewConnection(db.Config{URL: dbURL})
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer database.Close()
+
+	chunkRepo := postgres.NewChunkRepository(database)
+	ctx := context.Background()
+
+	if *status {
+		stats, err := chunkRepo.EmbeddingModels(ctx)
+		if err != nil {
+			log.Fatalf("Failed to list embedding models: %v", err)
+		}
+		for _, s := range stats {
+			fmt.Printf("%s (%d dimensions): %d chunks across %d videos\n", s.Model, s.Dimensions, s.Chunks, s.Videos)
+		}
+		return
+	}
+
+	embedder, err := embeddings.NewEmbedder(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure embedder: %v", err)
+	}
+
+	if *prune {
+		deleted, err := chunkRepo.PruneOtherModels(ctx, embedder.Model())
+		if err != nil {
+			log.Fatalf("Failed to prune: %v", err)
+		}
+		fmt.Printf("Deleted %d chunks from models other than %s\n", deleted, embedder.Model())
+		return
+	}
+
+	var videoIDs []string
+	for _, id := range strings.Split(*videos, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			videoIDs = append(videoIDs, id)
+		}
+	}
+
+	result, err := transcription.Reindex(ctx, chunkRepo, embedder, transcription.ReindexOptions{VideoIDs: videoIDs, All: *all})
+	if err != nil {
+		log.Fatalf("Reindex failed: %v", err)
+	}
+	fmt.Printf("Reindexed %d videos (%d chunks) with %s\n", result.Videos, result.Chunks, embedder.Model())
+	if len(result.Failed) > 0 {
+		log.Fatalf("%d videos failed, rerun to retry: %s", len(result.Failed), strings.Join(result.Failed, ", "))
+	}
+}
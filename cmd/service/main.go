@@ -8,6 +8,7 @@ import (
 	"github.com/joho/godotenv"
 	"jamesfarrell.me/youtube-to-text/internal/api"
 	"jamesfarrell.me/youtube-to-text/internal/config"
+	"jamesfarrell.me/youtube-to-text/internal/embeddings"
 	"jamesfarrell.me/youtube-to-text/internal/storage/db"
 	"jamesfarrell.me/youtube-to-text/internal/storage/postgres"
 )
@@ -24,6 +25,16 @@ func main() {
 		log.Fatal("SERVICE_API_KEY environment variable must be set")
 	}
 
+	embedder, err := embeddings.NewEmbedder(config.GetEmbeddingConfig())
+	if err != nil {
+		log.Fatalf("Failed to configure embedder: %v", err)
+	}
+
+	chatCfg := config.GetChatConfig()
+	if chatCfg.APIKey == "" && chatCfg.BaseURL == "" {
+		log.Println("Chat model not configured, /ask will fail: set CHAT_API_KEY, OPENAI_API_KEY or CHAT_BASE_URL")
+	}
+
 	// Initialize database connection
 	database, err := db.NewConnection(db.Config{URL: dbURL})
 	if err != nil {
@@ -33,9 +44,12 @@ func main() {
 
 	// Initialize repositories
 	videoRepo := postgres.NewVideoRepository(database)
+	searchRepo := postgres.NewSearchRepository(database)
+	collectionRepo := postgres.NewCollectionRepository(database)
+	subscriptionRepo := postgres.NewSubscriptionRepository(database)
 
 	// Initialize router with dependencies
-	router := api.NewRouter(videoRepo)
+	router := api.NewRouter(videoRepo, searchRepo, collectionRepo, subscriptionRepo, config.GetUploadConfig(), embedder, chatCfg)
 
 	// Start the HTTP server
 	log.Println("Starting HTTP server on :8080...")
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"log"
-	"os"
 
 	"github.com/joho/godotenv"
 	"jamesfarrell.me/youtube-to-text/internal/config"
+	"jamesfarrell.me/youtube-to-text/internal/embeddings"
+	"jamesfarrell.me/youtube-to-text/internal/ingest"
 	"jamesfarrell.me/youtube-to-text/internal/storage/db"
 	"jamesfarrell.me/youtube-to-text/internal/storage/postgres"
 	"jamesfarrell.me/youtube-to-text/internal/transcription"
@@ -17,11 +19,20 @@ func main() {
 	}
 
 	dbURL := config.GetDatabaseURL()
-	
-	apiKey := os.Getenv("LEMONFOX_API_KEY")
+	if dbURL == "" {
+		log.Fatal("DATABASE_URL environment variable must be set")
+	}
 
-	if apiKey == "" || dbURL == "" {
-		log.Fatal("LEMONFOX_API_KEY and DATABASE_URL environment variables must be set")
+	transcriber, err := transcription.NewTranscriber(config.GetTranscriberConfig())
+	if err != nil {
+		log.Fatalf("Failed to configure transcriber: %v", err)
+	}
+
+	// Only searchable videos need embeddings, so transcription can run
+	// without an embedder
+	embedder, err := embeddings.NewEmbedder(config.GetEmbeddingConfig())
+	if err != nil {
+		log.Printf("Embedder not configured, searchable videos will fail: %v", err)
 	}
 
 	// Initialize database connection
@@ -34,7 +45,14 @@ func main() {
 	log.Printf("Connected to database: %s", db.MaskDatabaseURL(dbURL))
 
 	transcriptionRepo := postgres.NewTranscriptionRepository(database)
-	transcriptionSvc := transcription.NewService(transcriptionRepo, apiKey, dbURL)
+	jobRepo := postgres.NewJobRepository(database)
+	transcriptionSvc := transcription.NewService(transcriptionRepo, jobRepo, transcriber, embedder, dbURL, config.GetWorkerConfig())
+
+	// Poll subscribed channels and playlists; new videos reach the queue
+	// through the same insert trigger as videos added through the API
+	subscriptionRepo := postgres.NewSubscriptionRepository(database)
+	videoRepo := postgres.NewVideoRepository(database)
+	go ingest.NewPoller(subscriptionRepo, videoRepo).Run(context.Background())
 
 	if err := transcriptionSvc.ListenForNewVideos(); err != nil {
 		log.Fatalf("Service error: %v", err)
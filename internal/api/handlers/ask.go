@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"jamesfarrell.me/youtube-to-text/internal/ask"
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+type AskHandler struct {
+	answerer *ask.Answerer
+}
+
+func NewAskHandler(answerer *ask.Answerer) *AskHandler {
+	return &AskHandler{answerer: answerer}
+}
+
+// Ask answers a question from the transcripts, as JSON or, when the request
+// sets stream or accepts text/event-stream, as server-sent events
+func (h *AskHandler) Ask(w http.ResponseWriter, r *http.Request) {
+	var req models.AskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := ask.Normalize(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Stream || strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		h.stream(w, r, req)
+		return
+	}
+
+	response, err := h.answerer.Ask(r.Context(), req, nil)
+	if err != nil {
+		http.Error(w, err.Error(), askErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// stream sends a "delta" event for each piece of the answer and a final
+// "done" event with the whole response and its citations. Failures before
+// the first event get a normal error status; later ones an "error" event.
+func (h *AskHandler) stream(w http.ResponseWriter, r *http.Request, req models.AskRequest) {
+	events, ok := newEventWriter(w)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	response, err := h.answerer.Ask(r.Context(), req, func(delta string) error {
+		return events.send("delta", map[string]string{"text": delta})
+	})
+	if err != nil {
+		if !events.started {
+			http.Error(w, err.Error(), askErrorStatus(err))
+			return
+		}
+		events.send("error", map[string]string{"error": err.Error()})
+		return
+	}
+	events.send("done", response)
+}
+
+// askErrorStatus maps a failed answer to its status: the chat model failing
+// is a bad gateway, and retrieval fails like a search
+func askErrorStatus(err error) int {
+	var completionErr *ask.CompletionError
+	if errors.As(err, &completionErr) {
+		return http.StatusBadGateway
+	}
+	return searchErrorStatus(err)
+}
+
+// eventWriter writes server-sent events, sending the headers with the first
+// event so errors before it can still set the status
+type eventWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	started bool
+}
+
+func newEventWriter(w http.ResponseWriter) (*eventWriter, bool) {
+	flusher, ok := w.(http.Flusher)
+	return &eventWriter{w: w, flusher: flusher}, ok
+}
+
+func (e *eventWriter) send(event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if !e.started {
+		e.w.Header().Set("Content-Type", "text/event-stream")
+		e.w.Header().Set("Cache-Control", "no-cache")
+		e.w.Header().Set("Connection", "keep-alive")
+		e.started = true
+	}
+	if _, err := fmt.Fprintf(e.w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	e.flusher.Flush()
+	return nil
+}
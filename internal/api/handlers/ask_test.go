@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"jamesfarrell.me/youtube-to-text/internal/ask"
+	"jamesfarrell.me/youtube-to-text/internal/config"
+	"jamesfarrell.me/youtube-to-text/internal/search"
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+	"jamesfarrell.me/youtube-to-text/internal/storage/postgres"
+)
+
+func TestEventWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	events, ok := newEventWriter(rec)
+	if !ok {
+		t.Fatal("httptest.ResponseRecorder should support flushing")
+	}
+
+	events.send("delta", map[string]string{"text": "Hello"})
+	events.send("done", map[string]any{"answer": "Hello [1]"})
+
+	if got := rec.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	want := "event: delta\ndata: {\"text\":\"Hello\"}\n\n" +
+		"event: done\ndata: {\"answer\":\"Hello [1]\"}\n\n"
+	if rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body.String(), want)
+	}
+	if !rec.Flushed {
+		t.Error("events should be flushed as they are written")
+	}
+}
+
+func TestAskErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{&ask.CompletionError{Err: errors.New("model loading")}, http.StatusBadGateway},
+		{&search.EmbeddingError{Err: errors.New("rate limited")}, http.StatusBadGateway},
+		{&postgres.ModelMismatchError{Model: "new", Available: []string{"old"}}, http.StatusConflict},
+		{errors.New("connection refused"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := askErrorStatus(tt.err); got != tt.want {
+			t.Errorf("askErrorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+type fakeRetriever struct {
+	results []models.SearchResult
+	err     error
+}
+
+func (f fakeRetriever) Search(ctx context.Context, req models.SearchRequest) (*models.SearchResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &models.SearchResponse{Results: f.results}, nil
+}
+
+// streamingChatServer streams the parts as chat completion chunks, then
+// either finishes or, when broken, sends an event that can't be parsed
+func streamingChatServer(broken bool, parts ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, part := range parts {
+			chunk, _ := json.Marshal(map[string]any{
+				"object":  "chat.completion.chunk",
+				"choices": []map[string]any{{"index": 0, "delta": map[string]string{"content": part}}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		if broken {
+			fmt.Fprint(w, "data: {\"choices\": [\n\n")
+			return
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+}
+
+// streamAsk posts a streaming question to a handler answering from the
+// retriever and the chat server
+func streamAsk(retriever ask.Retriever, chatURL string) *httptest.ResponseRecorder {
+	answerer := ask.NewAnswerer(retriever, config.ChatConfig{BaseURL: chatURL + "/v1", Model: "local-model"})
+	req := httptest.NewRequest(http.MethodPost, "/ask", strings.NewReader(`{"question": "How do I deploy?", "stream": true}`))
+	rec := httptest.NewRecorder()
+	NewAskHandler(answerer).Ask(rec, req)
+	return rec
+}
+
+func TestAskStream(t *testing.T) {
+	results := []models.SearchResult{{ChunkID: 11, VideoID: "abc", ChunkText: "Railway builds the Dockerfile.", StartTime: 75}}
+
+	t.Run("answer", func(t *testing.T) {
+		server := streamingChatServer(false, "Railway builds ", "it [1].")
+		defer server.Close()
+
+		rec := streamAsk(fakeRetriever{results: results}, server.URL)
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("status %d, Content-Type %q", rec.Code, rec.Header().Get("Content-Type"))
+		}
+		body := rec.Body.String()
+		want := "event: delta\ndata: {\"text\":\"Railway builds \"}\n\n" +
+			"event: delta\ndata: {\"text\":\"it [1].\"}\n\n" +
+			"event: done\ndata: "
+		if !strings.HasPrefix(body, want) {
+			t.Fatalf("body = %q, want deltas then done", body)
+		}
+		var response models.AskResponse
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(body, want))), &response); err != nil {
+			t.Fatalf("done event: %v", err)
+		}
+		if response.Answer != "Railway builds it [1]." || len(response.Citations) != 1 || response.Citations[0].ChunkID != 11 {
+			t.Errorf("done event = %+v", response)
+		}
+	})
+
+	t.Run("fails before the first event", func(t *testing.T) {
+		rec := streamAsk(fakeRetriever{err: &search.EmbeddingError{Err: errors.New("rate limited")}}, "http://127.0.0.1:0")
+		if rec.Code != http.StatusBadGateway {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusBadGateway)
+		}
+		if strings.Contains(rec.Body.String(), "event:") || rec.Header().Get("Content-Type") == "text/event-stream" {
+			t.Errorf("a failure before any event was streamed: %q", rec.Body.String())
+		}
+	})
+
+	t.Run("fails while streaming", func(t *testing.T) {
+		server := streamingChatServer(true, "Railway builds ")
+		defer server.Close()
+
+		rec := streamAsk(fakeRetriever{results: results}, server.URL)
+		if rec.Code != http.StatusOK {
+			t.Errorf("status = %d, want %d once streaming started", rec.Code, http.StatusOK)
+		}
+		body := rec.Body.String()
+		if !strings.HasPrefix(body, "event: delta\n") || !strings.Contains(body, "event: error\ndata: {\"error\":\"chat completion failed") {
+			t.Errorf("body = %q, want a delta then an error event", body)
+		}
+		if strings.Contains(body, "event: done") {
+			t.Errorf("body = %q, want no done event after an error", body)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"jamesfarrell.me/youtube-to-text/internal/ingest"
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+	"jamesfarrell.me/youtube-to-text/internal/storage/postgres"
+)
+
+type CollectionHandler struct {
+	repo *postgres.CollectionRepository
+}
+
+func NewCollectionHandler(repo *postgres.CollectionRepository) *CollectionHandler {
+	return &CollectionHandler{repo: repo}
+}
+
+// AddPlaylist imports every video of a YouTube playlist or channel
+func (h *CollectionHandler) AddPlaylist(w http.ResponseWriter, r *http.Request) {
+	var req models.CollectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req.URL = strings.TrimSpace(req.URL)
+	if req.URL == "" {
+		http.Error(w, "url is required", http.StatusBadRequest)
+		return
+	}
+
+	response, err := ingest.ImportPlaylist(r.Context(), h.repo, req.URL, req.IsSearchable)
+	if err != nil {
+		http.Error(w, err.Error(), importErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// AddFeed imports every episode of a podcast RSS or Atom feed
+func (h *CollectionHandler) AddFeed(w http.ResponseWriter, r *http.Request) {
+	var req models.CollectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req.URL = strings.TrimSpace(req.URL)
+	if req.URL == "" {
+		http.Error(w, "url is required", http.StatusBadRequest)
+		return
+	}
+
+	response, err := ingest.ImportFeed(r.Context(), h.repo, req.URL, req.IsSearchable)
+	if err != nil {
+		http.Error(w, err.Error(), importErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// importErrorStatus maps a failed import to its status: a playlist or feed
+// that couldn't be fetched is a bad gateway, anything else failed to store
+func importErrorStatus(err error) int {
+	var fetchErr *ingest.FetchError
+	if errors.As(err, &fetchErr) {
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
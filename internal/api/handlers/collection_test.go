@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"jamesfarrell.me/youtube-to-text/internal/ingest"
+)
+
+func TestImportErrorStatus(t *testing.T) {
+	fetchErr := &ingest.FetchError{Err: errors.New("URL is not a playlist or channel")}
+	if got := importErrorStatus(fetchErr); got != http.StatusBadGateway {
+		t.Errorf("importErrorStatus(fetch) = %d, want %d", got, http.StatusBadGateway)
+	}
+	storeErr := fmt.Errorf("video insert failed: %w", errors.New("connection refused"))
+	if got := importErrorStatus(storeErr); got != http.StatusInternalServerError {
+		t.Errorf("importErrorStatus(store) = %d, want %d", got, http.StatusInternalServerError)
+	}
+}
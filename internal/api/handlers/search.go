@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"jamesfarrell.me/youtube-to-text/internal/search"
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+	"jamesfarrell.me/youtube-to-text/internal/storage/postgres"
+)
+
+type SearchHandler struct {
+	searcher *search.Searcher
+}
+
+func NewSearchHandler(searcher *search.Searcher) *SearchHandler {
+	return &SearchHandler{searcher: searcher}
+}
+
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	var req models.SearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := search.Normalize(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.searcher.Search(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), searchErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// searchErrorStatus maps a failed search to its status: the embedding
+// provider failing is a bad gateway, and vectors from another model a conflict
+// until the chunks are reindexed
+func searchErrorStatus(err error) int {
+	var embeddingErr *search.EmbeddingError
+	var mismatch *postgres.ModelMismatchError
+	switch {
+	case errors.As(err, &embeddingErr):
+		return http.StatusBadGateway
+	case errors.As(err, &mismatch):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"jamesfarrell.me/youtube-to-text/internal/ingest"
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+	"jamesfarrell.me/youtube-to-text/internal/storage/postgres"
+)
+
+type SubscriptionHandler struct {
+	repo *postgres.SubscriptionRepository
+}
+
+func NewSubscriptionHandler(repo *postgres.SubscriptionRepository) *SubscriptionHandler {
+	return &SubscriptionHandler{repo: repo}
+}
+
+// CreateSubscription starts polling a channel or playlist for new videos
+func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var req models.SubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req.URL = strings.TrimSpace(req.URL)
+	if req.URL == "" {
+		http.Error(w, "url is required", http.StatusBadRequest)
+		return
+	}
+	if !validPollInterval(req.PollIntervalMinutes) {
+		http.Error(w, "pollIntervalMinutes must be positive", http.StatusBadRequest)
+		return
+	}
+
+	url, kind := ingest.NormalizePlaylistURL(req.URL)
+	subscription, err := h.repo.Create(r.Context(), url, kind, &req)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		http.Error(w, "already subscribed to "+url, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(subscription)
+}
+
+func (h *SubscriptionHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := h.repo.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subscriptions)
+}
+
+func (h *SubscriptionHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	subscription, err := h.repo.Get(r.Context(), mux.Vars(r)["id"])
+	if err == sql.ErrNoRows {
+		http.Error(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subscription)
+}
+
+// UpdateSubscription changes isSearchable, pollIntervalMinutes or active.
+// The source URL can't change; delete the subscription and create a new one.
+func (h *SubscriptionHandler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	var req models.SubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.URL != "" {
+		http.Error(w, "url can't be changed", http.StatusBadRequest)
+		return
+	}
+	if !validPollInterval(req.PollIntervalMinutes) {
+		http.Error(w, "pollIntervalMinutes must be positive", http.StatusBadRequest)
+		return
+	}
+
+	subscription, err := h.repo.Update(r.Context(), mux.Vars(r)["id"], &req)
+	if err == sql.ErrNoRows {
+		http.Error(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subscription)
+}
+
+// DeleteSubscription stops polling; videos already added are kept
+func (h *SubscriptionHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	err := h.repo.Delete(r.Context(), mux.Vars(r)["id"])
+	if err == sql.ErrNoRows {
+		http.Error(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validPollInterval(minutes *int) bool {
+	return minutes == nil || *minutes > 0
+}
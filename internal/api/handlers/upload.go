@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"jamesfarrell.me/youtube-to-text/internal/config"
+	"jamesfarrell.me/youtube-to-text/internal/storage/postgres"
+)
+
+// uploadExtensions are the audio and video containers accepted for upload;
+// the worker converts all of them to mp3 with ffmpeg
+var uploadExtensions = map[string]bool{
+	".mp3": true, ".m4a": true, ".wav": true, ".flac": true, ".ogg": true,
+	".opus": true, ".aac": true, ".webm": true, ".mp4": true, ".mov": true,
+	".mkv": true, ".avi": true, ".m4v": true,
+}
+
+type UploadHandler struct {
+	repo *postgres.VideoRepository
+	cfg  config.UploadConfig
+}
+
+func NewUploadHandler(repo *postgres.VideoRepository, cfg config.UploadConfig) *UploadHandler {
+	return &UploadHandler{repo: repo, cfg: cfg}
+}
+
+// upload is a recording received by AddUpload along with its form fields
+type upload struct {
+	FileName     string // name of the stored file inside the upload directory
+	Title        string
+	IsSearchable bool
+}
+
+// AddUpload stores a recording sent as multipart/form-data and queues it for
+// transcription. The form has a "file" part and optional "title" and
+// "isSearchable" fields.
+func (h *UploadHandler) AddUpload(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.cfg.MaxBytes)
+
+	received, err := receiveUpload(r, h.cfg.Dir)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, fmt.Sprintf("upload is larger than %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.repo.CreateUpload(r.Context(), received.FileName, received.Title, received.IsSearchable)
+	if err != nil {
+		os.Remove(filepath.Join(h.cfg.Dir, received.FileName))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"id": id})
+}
+
+// receiveUpload streams the multipart body to a new file in dir rather than
+// buffering it in memory, so recordings of any length can be uploaded
+func receiveUpload(r *http.Request, dir string) (*upload, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating upload directory: %w", err)
+	}
+
+	received := &upload{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, cleanUpload(dir, received, err)
+		}
+
+		switch part.FormName() {
+		case "file":
+			if received.FileName != "" {
+				return nil, cleanUpload(dir, received, errors.New("only one file can be uploaded"))
+			}
+			received.FileName, err = saveUploadPart(part, dir)
+		case "title":
+			var value []byte
+			value, err = io.ReadAll(io.LimitReader(part, 1024))
+			received.Title = strings.TrimSpace(string(value))
+		case "isSearchable":
+			var value []byte
+			value, err = io.ReadAll(io.LimitReader(part, 16))
+			if err == nil {
+				received.IsSearchable, err = strconv.ParseBool(strings.TrimSpace(string(value)))
+			}
+		}
+		part.Close()
+		if err != nil {
+			return nil, cleanUpload(dir, received, err)
+		}
+	}
+
+	if received.FileName == "" {
+		return nil, errors.New("file is required")
+	}
+	return received, nil
+}
+
+// saveUploadPart writes the file part under a random name that keeps the
+// original extension, which ffmpeg uses to pick a demuxer
+func saveUploadPart(part *multipart.Part, dir string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(part.FileName()))
+	if !uploadExtensions[ext] {
+		return "", fmt.Errorf("unsupported file type %q", ext)
+	}
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	name := hex.EncodeToString(random) + ext
+
+	file, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return "", fmt.Errorf("error creating upload file: %w", err)
+	}
+	_, err = io.Copy(file, part)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filepath.Join(dir, name))
+		return "", err
+	}
+	return name, nil
+}
+
+// cleanUpload removes a file already saved by a request that then failed
+func cleanUpload(dir string, received *upload, err error) error {
+	if received.FileName != "" {
+		os.Remove(filepath.Join(dir, received.FileName))
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func multipartRequest(t *testing.T, fields map[string]string, fileName string, content []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	if fileName != "" {
+		part, err := writer.CreateFormFile("file", fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(content)
+	}
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/uploads", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestReceiveUpload(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte("fake recording")
+	req := multipartRequest(t, map[string]string{"title": " Weekly sync ", "isSearchable": "true"}, "Meeting.MP4", content)
+
+	received, err := receiveUpload(req, dir)
+	if err != nil {
+		t.Fatalf("receiveUpload() error = %v", err)
+	}
+	if received.Title != "Weekly sync" || !received.IsSearchable {
+		t.Errorf("receiveUpload() = %+v, want title and isSearchable from form", received)
+	}
+	if filepath.Ext(received.FileName) != ".mp4" {
+		t.Errorf("stored file %q should keep the lower-cased extension", received.FileName)
+	}
+
+	stored, err := os.ReadFile(filepath.Join(dir, received.FileName))
+	if err != nil {
+		t.Fatalf("stored file missing: %v", err)
+	}
+	if !bytes.Equal(stored, content) {
+		t.Errorf("stored file = %q, want uploaded content", stored)
+	}
+}
+
+func TestReceiveUploadRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   map[string]string
+		fileName string
+	}{
+		{name: "missing file", fields: map[string]string{"title": "No file"}},
+		{name: "unsupported extension", fileName: "notes.pdf"},
+		{name: "invalid isSearchable", fields: map[string]string{"isSearchable": "maybe"}, fileName: "talk.mp3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			req := multipartRequest(t, tt.fields, tt.fileName, []byte("data"))
+			if _, err := receiveUpload(req, dir); err == nil {
+				t.Fatal("receiveUpload() returned no error")
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("rejected upload left %d files behind", len(entries))
+			}
+		})
+	}
+}
@@ -3,11 +3,18 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"jamesfarrell.me/youtube-to-text/internal/storage/models"
 	"jamesfarrell.me/youtube-to-text/internal/storage/postgres"
+	"jamesfarrell.me/youtube-to-text/internal/transcription"
 )
 
 type VideoHandler struct {
@@ -24,6 +31,11 @@ func (h *VideoHandler) AddVideo(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	video.Language = strings.ToLower(strings.TrimSpace(video.Language))
+	if video.Language != "" && !validLanguage(video.Language) {
+		http.Error(w, "language must be an ISO-639-1 code such as en or de", http.StatusBadRequest)
+		return
+	}
 
 	id, err := h.repo.Create(r.Context(), &video)
 	if err != nil {
@@ -51,4 +63,200 @@ func (h *VideoHandler) GetVideo(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(video)
-}
\ No newline at end of file
+}
+
+// GetTranscript renders the video's transcript as vtt (default), srt, txt,
+// json or md. Text and Markdown accept timestamps=true, paragraphs=false and
+// pause=<seconds> to tune how cues are joined, and VTT accepts words=true for
+// word timestamp tags. translation=true exports the English translation.
+// Speakers are shown by the names given to them.
+func (h *VideoHandler) GetTranscript(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = transcription.FormatVTT
+	}
+	contentType, ok := transcription.ExportContentTypes[format]
+	if !ok {
+		http.Error(w, "format must be one of vtt, srt, txt, json or md", http.StatusBadRequest)
+		return
+	}
+
+	opts, err := exportOptions(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var translation bool
+	if value := query.Get("translation"); value != "" {
+		if translation, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, fmt.Sprintf("invalid translation: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	video, transcript, ok := h.getTranscribedVideo(w, r)
+	if !ok {
+		return
+	}
+	opts.Title = video.Title
+
+	if translation {
+		if video.Translation == nil {
+			http.Error(w, "Translation not available", http.StatusNotFound)
+			return
+		}
+		transcript = video.Translation
+	}
+
+	body, err := transcription.Export(transcript.WithSpeakerNames(video.SpeakerNames), format, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write([]byte(body))
+}
+
+// GetSpeakers lists the speaker labels found in the video's transcript with
+// the names given to them
+func (h *VideoHandler) GetSpeakers(w http.ResponseWriter, r *http.Request) {
+	video, transcript, ok := h.getTranscribedVideo(w, r)
+	if !ok {
+		return
+	}
+	writeSpeakers(w, transcript, video.SpeakerNames)
+}
+
+// UpdateSpeakers names the video's speakers from a map of label to name, such
+// as {"SPEAKER_00": "Ada"}. It replaces all names; an empty name clears one.
+func (h *VideoHandler) UpdateSpeakers(w http.ResponseWriter, r *http.Request) {
+	var req map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	video, transcript, ok := h.getTranscribedVideo(w, r)
+	if !ok {
+		return
+	}
+
+	labels := transcript.Speakers()
+	names := map[string]string{}
+	for label, name := range req {
+		if !slices.Contains(labels, label) {
+			http.Error(w, fmt.Sprintf("unknown speaker label %q", label), http.StatusBadRequest)
+			return
+		}
+		if name = strings.TrimSpace(name); name != "" {
+			names[label] = name
+		}
+	}
+
+	if err := h.repo.SetSpeakerNames(r.Context(), video.ID, names); err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Video not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeSpeakers(w, transcript, names)
+}
+
+// getTranscribedVideo loads the video named in the path along with its
+// transcript, writing the error response when either is missing
+func (h *VideoHandler) getTranscribedVideo(w http.ResponseWriter, r *http.Request) (*models.Video, *models.Transcript, bool) {
+	video, err := h.repo.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Video not found", http.StatusNotFound)
+			return nil, nil, false
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	if video.Transcription == nil {
+		http.Error(w, "Transcript not available yet", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	transcript, err := videoTranscript(video)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	return video, transcript, true
+}
+
+func writeSpeakers(w http.ResponseWriter, transcript *models.Transcript, names map[string]string) {
+	speakers := []models.VideoSpeaker{}
+	for _, label := range transcript.Speakers() {
+		speakers = append(speakers, models.VideoSpeaker{Label: label, Name: names[label]})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.SpeakersResponse{Speakers: speakers})
+}
+
+// validLanguage accepts two-letter ISO-639-1 codes
+func validLanguage(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// videoTranscript returns the stored transcript, parsing the VTT for videos
+// transcribed before transcripts were stored
+func videoTranscript(video *models.Video) (*models.Transcript, error) {
+	if video.Transcript != nil {
+		return video.Transcript, nil
+	}
+	entries, err := transcription.ParseVTT(*video.Transcription)
+	if err != nil {
+		return nil, err
+	}
+	return models.TranscriptFromEntries(entries), nil
+}
+
+func exportOptions(query url.Values) (transcription.ExportOptions, error) {
+	opts := transcription.ExportOptions{Paragraphs: true}
+
+	if value := query.Get("timestamps"); value != "" {
+		timestamps, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("invalid timestamps: %w", err)
+		}
+		opts.Timestamps = timestamps
+	}
+	if value := query.Get("paragraphs"); value != "" {
+		paragraphs, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("invalid paragraphs: %w", err)
+		}
+		opts.Paragraphs = paragraphs
+	}
+	if value := query.Get("words"); value != "" {
+		words, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("invalid words: %w", err)
+		}
+		opts.WordTimestamps = words
+	}
+	if value := query.Get("pause"); value != "" {
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil || seconds <= 0 {
+			return opts, fmt.Errorf("pause must be a positive number of seconds")
+		}
+		opts.ParagraphPause = time.Duration(seconds * float64(time.Second))
+	}
+	return opts, nil
+}
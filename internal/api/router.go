@@ -6,10 +6,14 @@ import (
 	"github.com/gorilla/mux"
 	"jamesfarrell.me/youtube-to-text/internal/api/handlers"
 	"jamesfarrell.me/youtube-to-text/internal/api/middleware"
+	"jamesfarrell.me/youtube-to-text/internal/ask"
+	"jamesfarrell.me/youtube-to-text/internal/config"
+	"jamesfarrell.me/youtube-to-text/internal/embeddings"
+	"jamesfarrell.me/youtube-to-text/internal/search"
 	"jamesfarrell.me/youtube-to-text/internal/storage/postgres"
 )
 
-func NewRouter(videoRepo *postgres.VideoRepository) http.Handler {
+func NewRouter(videoRepo *postgres.VideoRepository, searchRepo *postgres.SearchRepository, collectionRepo *postgres.CollectionRepository, subscriptionRepo *postgres.SubscriptionRepository, uploadCfg config.UploadConfig, embedder embeddings.Embedder, chatCfg config.ChatConfig) http.Handler {
 	r := mux.NewRouter()
 
 	// Public routes
@@ -18,6 +22,12 @@ func NewRouter(videoRepo *postgres.VideoRepository) http.Handler {
 	// Protected routes
 	protected := r.PathPrefix("").Subrouter()
 	videoHandler := handlers.NewVideoHandler(videoRepo)
+	searcher := search.NewSearcher(searchRepo, embedder)
+	searchHandler := handlers.NewSearchHandler(searcher)
+	askHandler := handlers.NewAskHandler(ask.NewAnswerer(searcher, chatCfg))
+	collectionHandler := handlers.NewCollectionHandler(collectionRepo)
+	subscriptionHandler := handlers.NewSubscriptionHandler(subscriptionRepo)
+	uploadHandler := handlers.NewUploadHandler(videoRepo, uploadCfg)
 	
 	// Use AuthMiddleware instead of Auth
 	protected.Use(middleware.AuthMiddleware)
@@ -26,6 +36,28 @@ func NewRouter(videoRepo *postgres.VideoRepository) http.Handler {
 	videos := protected.PathPrefix("/videos").Subrouter()
 	videos.HandleFunc("", videoHandler.AddVideo).Methods(http.MethodPost)
 	videos.HandleFunc("/{id}", videoHandler.GetVideo).Methods(http.MethodGet)
+	videos.HandleFunc("/{id}/transcript", videoHandler.GetTranscript).Methods(http.MethodGet)
+	videos.HandleFunc("/{id}/speakers", videoHandler.GetSpeakers).Methods(http.MethodGet)
+	videos.HandleFunc("/{id}/speakers", videoHandler.UpdateSpeakers).Methods(http.MethodPut)
+
+	// Upload routes
+	protected.HandleFunc("/uploads", uploadHandler.AddUpload).Methods(http.MethodPost)
+
+	// Collection routes
+	protected.HandleFunc("/playlists", collectionHandler.AddPlaylist).Methods(http.MethodPost)
+	protected.HandleFunc("/feeds", collectionHandler.AddFeed).Methods(http.MethodPost)
+
+	// Subscription routes
+	subscriptions := protected.PathPrefix("/subscriptions").Subrouter()
+	subscriptions.HandleFunc("", subscriptionHandler.CreateSubscription).Methods(http.MethodPost)
+	subscriptions.HandleFunc("", subscriptionHandler.ListSubscriptions).Methods(http.MethodGet)
+	subscriptions.HandleFunc("/{id}", subscriptionHandler.GetSubscription).Methods(http.MethodGet)
+	subscriptions.HandleFunc("/{id}", subscriptionHandler.UpdateSubscription).Methods(http.MethodPatch)
+	subscriptions.HandleFunc("/{id}", subscriptionHandler.DeleteSubscription).Methods(http.MethodDelete)
+
+	// Search routes
+	protected.HandleFunc("/search", searchHandler.Search).Methods(http.MethodPost)
+	protected.HandleFunc("/ask", askHandler.Ask).Methods(http.MethodPost)
 
 	return r
 }
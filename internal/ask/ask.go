@@ -0,0 +1,175 @@
+package ask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+	"jamesfarrell.me/youtube-to-text/internal/config"
+	"jamesfarrell.me/youtube-to-text/internal/search"
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+const (
+	DefaultSources = 8
+	MaxSources     = 20
+)
+
+// noSourcesAnswer is returned without asking the model when no transcript
+// matches the question, so it has nothing to make an answer up from
+const noSourcesAnswer = "I couldn't find anything in the transcripts about that."
+
+// CompletionError means the chat model failed to answer
+type CompletionError struct {
+	Err error
+}
+
+func (e *CompletionError) Error() string {
+	return fmt.Sprintf("chat completion failed: %v", e.Err)
+}
+
+func (e *CompletionError) Unwrap() error {
+	return e.Err
+}
+
+// Retriever finds the transcript chunks to answer from; *search.Searcher
+// is the one used outside tests
+type Retriever interface {
+	Search(ctx context.Context, req models.SearchRequest) (*models.SearchResponse, error)
+}
+
+// Answerer answers questions from the transcripts: it retrieves the chunks
+// that match the question and has a chat model answer from them alone,
+// citing each one it uses
+type Answerer struct {
+	searcher  Retriever
+	client    *openai.Client
+	model     string
+	maxTokens int
+}
+
+// NewAnswerer uses the OpenAI chat API, or any server implementing it when
+// the config has a base URL
+func NewAnswerer(searcher Retriever, cfg config.ChatConfig) *Answerer {
+	clientCfg := openai.DefaultConfig(cfg.APIKey)
+	if cfg.BaseURL != "" {
+		clientCfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	}
+	return &Answerer{
+		searcher:  searcher,
+		client:    openai.NewClientWithConfig(clientCfg),
+		model:     cfg.Model,
+		maxTokens: cfg.MaxTokens,
+	}
+}
+
+// Normalize trims the question and fills in the default number of sources.
+// It returns an error for a request that can't be run.
+func Normalize(req *models.AskRequest) error {
+	req.Question = strings.TrimSpace(req.Question)
+	if req.Question == "" {
+		return fmt.Errorf("question is required")
+	}
+	if req.Limit <= 0 {
+		req.Limit = DefaultSources
+	}
+	req.Limit = min(req.Limit, MaxSources)
+
+	searchReq := searchRequest(*req)
+	if err := search.Normalize(&searchReq); err != nil {
+		return err
+	}
+	req.Mode = searchReq.Mode
+	return nil
+}
+
+func searchRequest(req models.AskRequest) models.SearchRequest {
+	return models.SearchRequest{
+		Query:        req.Question,
+		Limit:        req.Limit,
+		Mode:         req.Mode,
+		SearchFilter: req.SearchFilter,
+	}
+}
+
+// Ask answers a normalized request. When onDelta is set the answer is
+// streamed from the model and each piece is passed to onDelta as it
+// arrives; the response still holds the whole answer.
+func (a *Answerer) Ask(ctx context.Context, req models.AskRequest, onDelta func(string) error) (*models.AskResponse, error) {
+	found, err := a.searcher.Search(ctx, searchRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	sources := sourcesFromResults(found.Results)
+
+	// No model is asked when nothing matched, so none is reported
+	response := &models.AskResponse{Citations: []models.Citation{}}
+	if len(sources) == 0 {
+		response.Answer = noSourcesAnswer
+		if onDelta != nil {
+			if err := onDelta(noSourcesAnswer); err != nil {
+				return nil, err
+			}
+		}
+		return response, nil
+	}
+
+	answer, err := a.complete(ctx, buildMessages(req.Question, sources), onDelta)
+	if err != nil {
+		return nil, err
+	}
+	response.Answer = answer
+	response.Model = a.model
+	response.Citations = citedSources(answer, sources)
+	return response, nil
+}
+
+// complete sends the messages to the chat model, streaming the answer to
+// onDelta when it is set
+func (a *Answerer) complete(ctx context.Context, messages []openai.ChatCompletionMessage, onDelta func(string) error) (string, error) {
+	req := openai.ChatCompletionRequest{
+		Model:     a.model,
+		Messages:  messages,
+		MaxTokens: a.maxTokens,
+	}
+
+	if onDelta == nil {
+		resp, err := a.client.CreateChatCompletion(ctx, req)
+		if err != nil {
+			return "", &CompletionError{Err: err}
+		}
+		if len(resp.Choices) == 0 {
+			return "", &CompletionError{Err: fmt.Errorf("response has no choices")}
+		}
+		return resp.Choices[0].Message.Content, nil
+	}
+
+	req.Stream = true
+	stream, err := a.client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return "", &CompletionError{Err: err}
+	}
+	defer stream.Close()
+
+	var answer strings.Builder
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return answer.String(), nil
+		}
+		if err != nil {
+			return "", &CompletionError{Err: err}
+		}
+		if len(resp.Choices) == 0 || resp.Choices[0].Delta.Content == "" {
+			continue
+		}
+		delta := resp.Choices[0].Delta.Content
+		answer.WriteString(delta)
+		if err := onDelta(delta); err != nil {
+			return "", err
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package ask
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"jamesfarrell.me/youtube-to-text/internal/config"
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+func testResults() []models.SearchResult {
+	return []models.SearchResult{
+		{ChunkID: 11, VideoID: "abc", Title: "Deploying Go", ChunkText: "Railway builds the Dockerfile.", StartTime: 75},
+		{ChunkID: 12, VideoID: "def", ChunkText: "Set the PORT variable.", StartTime: 3725},
+		{ChunkID: 13, VideoID: "abc", Title: "Deploying Go", ChunkText: "Logs are in the dashboard.", StartTime: 300},
+	}
+}
+
+func testSources() []models.Citation {
+	return sourcesFromResults(testResults())
+}
+
+func TestBuildMessages(t *testing.T) {
+	messages := buildMessages("How do I deploy?", testSources())
+	if len(messages) != 2 || messages[0].Content != systemPrompt {
+		t.Fatalf("buildMessages() = %+v, want the system prompt and one user message", messages)
+	}
+
+	prompt := messages[1].Content
+	for _, want := range []string{
+		"[1] \"Deploying Go\" at 1:15\nRailway builds the Dockerfile.",
+		// Untitled videos fall back to their id
+		"[2] \"def\" at 1:02:05\nSet the PORT variable.",
+		"Question: How do I deploy?",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+}
+
+func TestCitedSources(t *testing.T) {
+	sources := testSources()
+	answer := "Push a Dockerfile [3] and Railway builds it [1]. Set PORT [2, 1] [7]."
+
+	cited := citedSources(answer, sources)
+	var numbers []int
+	for _, c := range cited {
+		numbers = append(numbers, c.Number)
+	}
+	if fmt.Sprint(numbers) != "[3 1 2]" {
+		t.Fatalf("citedSources() numbers = %v, want [3 1 2]", numbers)
+	}
+	if cited[0].VideoID != "abc" || cited[0].StartTime != 300 || cited[0].ChunkID != 13 {
+		t.Errorf("citation [3] = %+v, want chunk 13 of abc at 300s", cited[0])
+	}
+
+	if got := citedSources("No citations here.", sources); len(got) != 0 {
+		t.Errorf("citedSources() = %v, want none", got)
+	}
+}
+
+// chatServer mocks /chat/completions, answering with the given parts either
+// as one message or, for streaming requests, as one event per part
+func chatServer(t *testing.T, parts ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var req struct {
+			Model     string `json:"model"`
+			Stream    bool   `json:"stream"`
+			MaxTokens int    `json:"max_tokens"`
+			Messages  []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("bad request body: %v", err)
+		}
+		if req.Model != "local-model" || req.MaxTokens != 200 || len(req.Messages) != 2 {
+			t.Errorf("request = %+v", req)
+		}
+
+		if !req.Stream {
+			json.NewEncoder(w).Encode(map[string]any{
+				"id":      "chatcmpl-1",
+				"object":  "chat.completion",
+				"choices": []map[string]any{{"index": 0, "message": map[string]string{"role": "assistant", "content": strings.Join(parts, "")}, "finish_reason": "stop"}},
+			})
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, part := range parts {
+			chunk, _ := json.Marshal(map[string]any{
+				"id":      "chatcmpl-1",
+				"object":  "chat.completion.chunk",
+				"choices": []map[string]any{{"index": 0, "delta": map[string]string{"content": part}}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+}
+
+func testAnswerer(url string, retriever Retriever) *Answerer {
+	return NewAnswerer(retriever, config.ChatConfig{BaseURL: url + "/v1/", Model: "local-model", MaxTokens: 200})
+}
+
+// fakeRetriever returns its results, or its error, and records the request
+type fakeRetriever struct {
+	results []models.SearchResult
+	err     error
+	req     models.SearchRequest
+}
+
+func (f *fakeRetriever) Search(ctx context.Context, req models.SearchRequest) (*models.SearchResponse, error) {
+	f.req = req
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &models.SearchResponse{Results: f.results}, nil
+}
+
+func TestComplete(t *testing.T) {
+	server := chatServer(t, "Railway builds ", "the Dockerfile [1].")
+	defer server.Close()
+
+	answer, err := testAnswerer(server.URL, nil).complete(context.Background(), buildMessages("How?", testSources()), nil)
+	if err != nil {
+		t.Fatalf("complete() error = %v", err)
+	}
+	if answer != "Railway builds the Dockerfile [1]." {
+		t.Errorf("complete() = %q", answer)
+	}
+}
+
+func TestCompleteStream(t *testing.T) {
+	server := chatServer(t, "Railway builds ", "the Dockerfile ", "[1].")
+	defer server.Close()
+
+	var deltas []string
+	answer, err := testAnswerer(server.URL, nil).complete(context.Background(), buildMessages("How?", testSources()), func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("complete() error = %v", err)
+	}
+	if len(deltas) != 3 || answer != "Railway builds the Dockerfile [1]." {
+		t.Errorf("complete() = %q from deltas %q", answer, deltas)
+	}
+}
+
+func TestCompleteError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error": {"message": "model loading", "type": "server_error"}}`))
+	}))
+	defer server.Close()
+
+	_, err := testAnswerer(server.URL, nil).complete(context.Background(), buildMessages("How?", testSources()), nil)
+	var completionErr *CompletionError
+	if !errors.As(err, &completionErr) {
+		t.Fatalf("complete() error = %v, want a CompletionError", err)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	req := models.AskRequest{Question: "  How do I deploy?  ", Limit: 100}
+	if err := Normalize(&req); err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if req.Question != "How do I deploy?" || req.Limit != MaxSources || req.Mode != models.SearchModeHybrid {
+		t.Errorf("Normalize() = %+v", req)
+	}
+
+	for _, bad := range []models.AskRequest{{Question: " "}, {Question: "How?", Mode: "fuzzy"}} {
+		if err := Normalize(&bad); err == nil {
+			t.Errorf("Normalize(%+v) expected an error", bad)
+		}
+	}
+}
+
+func TestAsk(t *testing.T) {
+	server := chatServer(t, "Railway builds the Dockerfile [1]; ", "check the logs [3].")
+	defer server.Close()
+	retriever := &fakeRetriever{results: testResults()}
+
+	req := models.AskRequest{Question: "How do I deploy?", Limit: 3, Mode: models.SearchModeKeyword, SearchFilter: models.SearchFilter{ChannelID: "UC123"}}
+	var deltas []string
+	response, err := testAnswerer(server.URL, retriever).Ask(context.Background(), req, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Ask() error = %v", err)
+	}
+
+	if retriever.req.Query != req.Question || retriever.req.Limit != 3 || retriever.req.Mode != models.SearchModeKeyword || retriever.req.ChannelID != "UC123" {
+		t.Errorf("search request = %+v, want the question with the request's limit, mode and filter", retriever.req)
+	}
+	if len(deltas) != 2 || response.Answer != "Railway builds the Dockerfile [1]; check the logs [3]." {
+		t.Errorf("Ask() answer = %q from deltas %q", response.Answer, deltas)
+	}
+	if response.Model != "local-model" {
+		t.Errorf("Ask() model = %q, want local-model", response.Model)
+	}
+	if len(response.Citations) != 2 || response.Citations[0].ChunkID != 11 || response.Citations[1].ChunkID != 13 {
+		t.Errorf("Ask() citations = %+v, want chunks 11 and 13", response.Citations)
+	}
+}
+
+func TestAskWithoutSources(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the chat model was asked without any sources")
+	}))
+	defer server.Close()
+
+	var deltas []string
+	response, err := testAnswerer(server.URL, &fakeRetriever{}).Ask(context.Background(), models.AskRequest{Question: "Who won?"}, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Ask() error = %v", err)
+	}
+	if response.Answer != noSourcesAnswer || len(deltas) != 1 || deltas[0] != noSourcesAnswer {
+		t.Errorf("Ask() = %q from deltas %q, want the no-sources answer", response.Answer, deltas)
+	}
+	if response.Model != "" || response.Citations == nil || len(response.Citations) != 0 {
+		t.Errorf("Ask() = %+v, want no model and an empty citation list", response)
+	}
+}
+
+func TestAskSearchError(t *testing.T) {
+	searchErr := errors.New("connection refused")
+	_, err := testAnswerer("http://127.0.0.1:0", &fakeRetriever{err: searchErr}).Ask(context.Background(), models.AskRequest{Question: "How?"}, nil)
+	if !errors.Is(err, searchErr) {
+		t.Errorf("Ask() error = %v, want the search error", err)
+	}
+}
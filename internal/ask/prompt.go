@@ -0,0 +1,85 @@
+package ask
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+const systemPrompt = `You answer questions about video and podcast transcripts.
+Use only the numbered transcript excerpts you are given, never outside knowledge.
+Cite the excerpt behind every statement with its number in square brackets, like [1] or [2][3].
+If the excerpts don't answer the question, say so briefly instead of guessing.`
+
+// citationPattern matches [1], and lists such as [1, 3] that models write
+// despite being asked not to
+var citationPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// sourcesFromResults numbers the retrieved chunks from 1, in rank order
+func sourcesFromResults(results []models.SearchResult) []models.Citation {
+	sources := make([]models.Citation, len(results))
+	for i, result := range results {
+		sources[i] = models.Citation{
+			Number:    i + 1,
+			ChunkID:   result.ChunkID,
+			VideoID:   result.VideoID,
+			Title:     result.Title,
+			StartTime: result.StartTime,
+			EndTime:   result.EndTime,
+			Text:      result.ChunkText,
+		}
+	}
+	return sources
+}
+
+// buildMessages lays the sources out as numbered excerpts, each with its
+// video and time, followed by the question
+func buildMessages(question string, sources []models.Citation) []openai.ChatCompletionMessage {
+	var prompt strings.Builder
+	prompt.WriteString("Transcript excerpts:\n\n")
+	for _, source := range sources {
+		title := source.Title
+		if title == "" {
+			title = source.VideoID
+		}
+		fmt.Fprintf(&prompt, "[%d] %q at %s\n%s\n\n", source.Number, title, formatClock(source.StartTime), source.Text)
+	}
+	fmt.Fprintf(&prompt, "Question: %s", question)
+
+	return []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: prompt.String()},
+	}
+}
+
+// citedSources returns the sources the answer cites, in the order they are
+// first cited. Numbers that match no source are ignored.
+func citedSources(answer string, sources []models.Citation) []models.Citation {
+	cited := []models.Citation{}
+	seen := map[int]bool{}
+	for _, match := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		for _, field := range strings.Split(match[1], ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil || n < 1 || n > len(sources) || seen[n] {
+				continue
+			}
+			seen[n] = true
+			cited = append(cited, sources[n-1])
+		}
+	}
+	return cited
+}
+
+// formatClock writes a readable H:MM:SS or M:SS offset
+func formatClock(seconds float64) string {
+	total := int(seconds)
+	h, m, s := total/3600, total/60%60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
@@ -0,0 +1,31 @@
+package config
+
+import "os"
+
+// ChatConfig configures the chat-completion model that answers questions
+// about the transcripts
+type ChatConfig struct {
+	// BaseURL points at any server implementing /chat/completions; empty
+	// uses OpenAI
+	BaseURL string
+	APIKey  string
+	Model   string
+	// MaxTokens bounds the length of an answer
+	MaxTokens int
+}
+
+// GetChatConfig reads CHAT_BASE_URL, CHAT_API_KEY (falling back to
+// OPENAI_API_KEY), CHAT_MODEL (default gpt-4o-mini) and CHAT_MAX_TOKENS
+// (default 800) from the environment
+func GetChatConfig() ChatConfig {
+	cfg := ChatConfig{
+		BaseURL:   os.Getenv("CHAT_BASE_URL"),
+		APIKey:    os.Getenv("CHAT_API_KEY"),
+		Model:     getEnvString("CHAT_MODEL", "gpt-4o-mini"),
+		MaxTokens: getEnvInt("CHAT_MAX_TOKENS", 800),
+	}
+	if cfg.APIKey == "" {
+		cfg.APIKey = os.Getenv("OPENAI_API_KEY")
+	}
+	return cfg
+}
@@ -0,0 +1,37 @@
+package config
+
+import "os"
+
+// EmbeddingConfig selects and configures the embedding provider used for
+// search chunks and queries
+type EmbeddingConfig struct {
+	// Provider is one of "openai", "openai-compatible" or "hash"
+	Provider string
+	APIKey   string
+	// BaseURL is required for "openai-compatible", e.g. http://localhost:11434/v1
+	BaseURL string
+	Model   string
+	// Dimensions asks models that support it, such as text-embedding-3-small,
+	// for shorter vectors; 0 keeps the model's own size
+	Dimensions int
+	// BatchSize is how many texts are sent in one request
+	BatchSize int
+}
+
+// GetEmbeddingConfig reads the embedding provider settings from the
+// environment. The provider defaults to OpenAI, and the API key falls back to
+// OPENAI_API_KEY when EMBEDDING_API_KEY is not set.
+func GetEmbeddingConfig() EmbeddingConfig {
+	cfg := EmbeddingConfig{
+		Provider:   getEnvString("EMBEDDING_PROVIDER", "openai"),
+		APIKey:     os.Getenv("EMBEDDING_API_KEY"),
+		BaseURL:    os.Getenv("EMBEDDING_BASE_URL"),
+		Model:      os.Getenv("EMBEDDING_MODEL"),
+		Dimensions: getEnvNonNegativeInt("EMBEDDING_DIMENSIONS", 0),
+		BatchSize:  getEnvInt("EMBEDDING_BATCH_SIZE", 100),
+	}
+	if cfg.APIKey == "" && cfg.Provider == "openai" {
+		cfg.APIKey = os.Getenv("OPENAI_API_KEY")
+	}
+	return cfg
+}
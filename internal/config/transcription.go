@@ -0,0 +1,50 @@
+package config
+
+import "os"
+
+// TranscriberConfig selects and configures the speech-to-text provider
+type TranscriberConfig struct {
+	// Provider is one of "lemonfox", "openai" or "openai-compatible"
+	Provider string
+	APIKey   string
+	// BaseURL is required for "openai-compatible", e.g. http://localhost:8000/v1
+	BaseURL string
+	Model   string
+	// ResponseFormat is the format requested from the provider: "verbose_json"
+	// (the default), "srt" or "vtt"
+	ResponseFormat string
+	// WordTimestamps asks for word timings along with segments
+	WordTimestamps bool
+	// SpeakerLabels asks the provider to diarize, where it supports it
+	SpeakerLabels bool
+}
+
+// GetTranscriberConfig reads the transcription provider settings from the environment.
+// The provider defaults to Lemonfox, and the API key falls back to the
+// provider-specific variable when TRANSCRIPTION_API_KEY is not set.
+func GetTranscriberConfig() TranscriberConfig {
+	cfg := TranscriberConfig{
+		Provider: os.Getenv("TRANSCRIPTION_PROVIDER"),
+		APIKey:   os.Getenv("TRANSCRIPTION_API_KEY"),
+		BaseURL:  os.Getenv("TRANSCRIPTION_BASE_URL"),
+		Model:    os.Getenv("TRANSCRIPTION_MODEL"),
+
+		ResponseFormat: os.Getenv("TRANSCRIPTION_RESPONSE_FORMAT"),
+		WordTimestamps: getEnvBool("TRANSCRIPTION_WORD_TIMESTAMPS", true),
+		SpeakerLabels:  getEnvBool("TRANSCRIPTION_SPEAKER_LABELS", false),
+	}
+	if cfg.Provider == "" {
+		cfg.Provider = "lemonfox"
+	}
+
+	if cfg.APIKey == "" {
+		switch cfg.Provider {
+		case "lemonfox":
+			cfg.APIKey = os.Getenv("LEMONFOX_API_KEY")
+		case "openai":
+			cfg.APIKey = os.Getenv("OPENAI_API_KEY")
+		}
+	}
+
+	return cfg
+}
@@ -0,0 +1,17 @@
+package config
+
+// UploadConfig controls where uploaded recordings are stored. The API and the
+// transcription worker must see the same directory, for example a shared volume.
+type UploadConfig struct {
+	Dir      string
+	MaxBytes int64
+}
+
+// GetUploadConfig reads UPLOAD_DIR (default "uploads") and UPLOAD_MAX_MB
+// (default 2048) from the environment
+func GetUploadConfig() UploadConfig {
+	return UploadConfig{
+		Dir:      getEnvString("UPLOAD_DIR", "uploads"),
+		MaxBytes: int64(getEnvInt("UPLOAD_MAX_MB", 2048)) * 1024 * 1024,
+	}
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WorkerConfig controls how many videos the transcription worker handles at
+// once and how many of them may be in each pipeline stage
+type WorkerConfig struct {
+	Workers               int
+	DownloadConcurrency   int
+	TranscribeConcurrency int
+	EmbedConcurrency      int
+	// SegmentConcurrency is how many segments of one video are sent to the
+	// transcription provider at once
+	SegmentConcurrency int
+	// SegmentOverlap extends each split segment into the next one so words at
+	// the cut are heard whole by the provider
+	SegmentOverlap time.Duration
+	// CaptionPolicy is "never" (always transcribe), "manual" (reuse
+	// creator-uploaded captions) or "any" (also reuse auto-generated ones)
+	CaptionPolicy string
+	// CaptionLanguages is a yt-dlp --sub-langs pattern such as "en.*"
+	CaptionLanguages string
+	// UploadDir is where the API stores uploaded recordings
+	UploadDir string
+	// MaxDownloadBytes caps files downloaded straight from a URL, such as
+	// podcast enclosures
+	MaxDownloadBytes int64
+}
+
+// GetWorkerConfig reads the worker pool settings from the environment
+func GetWorkerConfig() WorkerConfig {
+	return WorkerConfig{
+		Workers:               getEnvInt("WORKER_COUNT", 2),
+		DownloadConcurrency:   getEnvInt("DOWNLOAD_CONCURRENCY", 1),
+		TranscribeConcurrency: getEnvInt("TRANSCRIBE_CONCURRENCY", 2),
+		EmbedConcurrency:      getEnvInt("EMBED_CONCURRENCY", 2),
+		SegmentConcurrency:    getEnvInt("SEGMENT_CONCURRENCY", 3),
+		SegmentOverlap:        getEnvDuration("SEGMENT_OVERLAP", 0),
+		CaptionPolicy:         getEnvChoice("CAPTION_POLICY", "never", "never", "manual", "any"),
+		CaptionLanguages:      getEnvString("CAPTION_LANGUAGES", "en.*"),
+		UploadDir:             GetUploadConfig().Dir,
+		MaxDownloadBytes:      int64(getEnvInt("DOWNLOAD_MAX_MB", 2048)) * 1024 * 1024,
+	}
+}
+
+func getEnvString(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvChoice returns the environment variable, which must be one of
+// choices, or the fallback when it is unset
+func getEnvChoice(key string, fallback string, choices ...string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	if !slices.Contains(choices, value) {
+		log.Fatalf("%s must be one of %s, got %q", key, strings.Join(choices, ", "), value)
+	}
+	return value
+}
+
+// getEnvInt returns the positive integer in the environment variable, or the
+// fallback when it is unset
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		log.Fatalf("%s must be a positive integer, got %q", key, value)
+	}
+	return n
+}
+
+// getEnvDuration parses a duration such as "2s" from the environment, or
+// returns the fallback when it is unset
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Fatalf("%s must be a non-negative duration such as 2s, got %q", key, value)
+	}
+	return d
+}
+
+// getEnvBool parses a boolean such as "true" or "0" from the environment, or
+// returns the fallback when it is unset
+func getEnvBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("%s must be true or false, got %q", key, value)
+	}
+	return b
+}
+
+// getEnvNonNegativeInt is getEnvInt for settings where 0 means "off" or "use
+// the default"
+func getEnvNonNegativeInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Fatalf("%s must be a non-negative integer, got %q", key, value)
+	}
+	return n
+}
@@ -0,0 +1,49 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+
+	"jamesfarrell.me/youtube-to-text/internal/config"
+)
+
+// Embedder turns texts into embedding vectors for semantic search
+type Embedder interface {
+	// Embed returns one vector per text, in the same order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Model names the model the vectors come from. Vectors from different
+	// models can't be compared.
+	Model() string
+}
+
+// NewEmbedder builds the Embedder selected by the config
+func NewEmbedder(cfg config.EmbeddingConfig) (Embedder, error) {
+	switch cfg.Provider {
+	case "openai":
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("openai embedder requires an API key")
+		}
+		return NewOpenAIEmbedder(cfg.APIKey, cfg.Model, cfg.Dimensions, cfg.BatchSize), nil
+	case "openai-compatible":
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("openai-compatible embedder requires a base URL")
+		}
+		if cfg.Model == "" {
+			return nil, fmt.Errorf("openai-compatible embedder requires a model")
+		}
+		return NewOpenAICompatibleEmbedder(cfg.BaseURL, cfg.APIKey, cfg.Model, cfg.Dimensions, cfg.BatchSize), nil
+	case "hash":
+		return NewHashEmbedder(cfg.Dimensions), nil
+	default:
+		return nil, fmt.Errorf("unknown embedding provider: %q", cfg.Provider)
+	}
+}
+
+// Embed embeds a single text, such as a search query
+func Embed(ctx context.Context, embedder Embedder, text string) ([]float32, error) {
+	vectors, err := embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
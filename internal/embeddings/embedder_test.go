@@ -0,0 +1,159 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"jamesfarrell.me/youtube-to-text/internal/config"
+)
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+func TestHashEmbedder(t *testing.T) {
+	embedder := NewHashEmbedder(256)
+	vectors, err := embedder.Embed(context.Background(), []string{
+		"Deploying Go services to Railway",
+		"deploying go SERVICES to railway!",
+		"A recipe for sourdough bread",
+		"",
+	})
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+
+	if len(vectors[0]) != 256 {
+		t.Fatalf("got %d dimensions, want 256", len(vectors[0]))
+	}
+	if !reflect.DeepEqual(vectors[0], vectors[1]) {
+		t.Error("case and punctuation should not change the vector")
+	}
+	if got := cosine(vectors[0], vectors[0]); math.Abs(got-1) > 1e-6 {
+		t.Errorf("vectors should be unit length, cosine with itself = %v", got)
+	}
+	if related, unrelated := cosine(vectors[0], vectors[1]), cosine(vectors[0], vectors[2]); related <= unrelated {
+		t.Errorf("similar texts scored %v, unrelated %v", related, unrelated)
+	}
+	for _, v := range vectors[3] {
+		if v != 0 {
+			t.Fatal("empty text should give the zero vector")
+		}
+	}
+
+	again, _ := embedder.Embed(context.Background(), []string{"Deploying Go services to Railway"})
+	if !reflect.DeepEqual(again[0], vectors[0]) {
+		t.Error("Embed() is not deterministic")
+	}
+	if embedder.Model() != "hash-256" {
+		t.Errorf("Model() = %q", embedder.Model())
+	}
+}
+
+func TestOpenAICompatibleEmbedderBatches(t *testing.T) {
+	var batches [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var req struct {
+			Input      []string `json:"input"`
+			Model      string   `json:"model"`
+			Dimensions int      `json:"dimensions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("bad request body: %v", err)
+		}
+		if req.Model != "nomic-embed-text" || req.Dimensions != 2 {
+			t.Errorf("model = %q, dimensions = %d", req.Model, req.Dimensions)
+		}
+		batches = append(batches, req.Input)
+
+		// Answer in reverse order; the index says where each vector goes
+		type item struct {
+			Object    string    `json:"object"`
+			Embedding []float32 `json:"embedding"`
+			Index     int       `json:"index"`
+		}
+		var data []item
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			data = append(data, item{Object: "embedding", Embedding: []float32{float32(len(req.Input[i])), 0}, Index: i})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"object": "list", "data": data, "model": req.Model})
+	}))
+	defer server.Close()
+
+	embedder := NewOpenAICompatibleEmbedder(server.URL+"/v1/", "", "nomic-embed-text", 2, 2)
+	// The size tells the model's vectors apart from full-size ones, but the
+	// request still names the model alone
+	if embedder.Model() != "nomic-embed-text@2" {
+		t.Errorf("Model() = %q, want nomic-embed-text@2", embedder.Model())
+	}
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	vectors, err := embedder.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+
+	if len(batches) != 3 || len(batches[2]) != 1 {
+		t.Errorf("batches = %v, want 3 requests of at most 2 texts", batches)
+	}
+	for i, vector := range vectors {
+		if int(vector[0]) != len(texts[i]) {
+			t.Errorf("vector %d = %v, want the vector for %q", i, vector, texts[i])
+		}
+	}
+}
+
+func TestOpenAICompatibleEmbedderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": {"message": "rate limited", "type": "rate_limit"}}`))
+	}))
+	defer server.Close()
+
+	embedder := NewOpenAICompatibleEmbedder(server.URL, "", "model", 0, 0)
+	if _, err := Embed(context.Background(), embedder, "query"); err == nil {
+		t.Fatal("Embed() expected an error for a 429 response")
+	}
+}
+
+func TestNewEmbedder(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       config.EmbeddingConfig
+		wantModel string
+		wantErr   bool
+	}{
+		{name: "openai", cfg: config.EmbeddingConfig{Provider: "openai", APIKey: "key"}, wantModel: "text-embedding-ada-002"},
+		{name: "openai model", cfg: config.EmbeddingConfig{Provider: "openai", APIKey: "key", Model: "text-embedding-3-small"}, wantModel: "text-embedding-3-small"},
+		{name: "openai shortened", cfg: config.EmbeddingConfig{Provider: "openai", APIKey: "key", Model: "text-embedding-3-small", Dimensions: 512}, wantModel: "text-embedding-3-small@512"},
+		{name: "openai without key", cfg: config.EmbeddingConfig{Provider: "openai"}, wantErr: true},
+		{name: "compatible", cfg: config.EmbeddingConfig{Provider: "openai-compatible", BaseURL: "http://localhost:11434/v1", Model: "nomic-embed-text"}, wantModel: "nomic-embed-text"},
+		{name: "compatible without model", cfg: config.EmbeddingConfig{Provider: "openai-compatible", BaseURL: "http://localhost:11434/v1"}, wantErr: true},
+		{name: "hash", cfg: config.EmbeddingConfig{Provider: "hash"}, wantModel: "hash-1536"},
+		{name: "unknown", cfg: config.EmbeddingConfig{Provider: "word2vec"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embedder, err := NewEmbedder(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewEmbedder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && embedder.Model() != tt.wantModel {
+				t.Errorf("Model() = %q, want %q", embedder.Model(), tt.wantModel)
+			}
+		})
+	}
+}
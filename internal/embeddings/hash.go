@@ -0,0 +1,75 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// defaultHashDimensions matches the ivfflat index in setup.sql
+const defaultHashDimensions = 1536
+
+// HashEmbedder is a deterministic stand-in for tests and offline development.
+// Each word is hashed into one of the dimensions, so texts sharing words are
+// similar, but it knows nothing about meaning.
+type HashEmbedder struct {
+	dimensions int
+}
+
+// NewHashEmbedder builds vectors of the given size, 1536 when not positive
+func NewHashEmbedder(dimensions int) *HashEmbedder {
+	if dimensions <= 0 {
+		dimensions = defaultHashDimensions
+	}
+	return &HashEmbedder{dimensions: dimensions}
+}
+
+func (e *HashEmbedder) Model() string {
+	return fmt.Sprintf("hash-%d", e.dimensions)
+}
+
+func (e *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = e.embed(text)
+	}
+	return vectors, nil
+}
+
+// embed adds ±1 for each word at the dimension its hash picks, then scales the
+// vector to unit length. Text without words gives the zero vector.
+func (e *HashEmbedder) embed(text string) []float32 {
+	vector := make([]float32, e.dimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+
+		// The top bit picks the sign, so unrelated words tend to cancel
+		// rather than pile up
+		if sum>>63 == 1 {
+			vector[sum%uint64(e.dimensions)]--
+		} else {
+			vector[sum%uint64(e.dimensions)]++
+		}
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return vector
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range vector {
+		vector[i] *= scale
+	}
+	return vector
+}
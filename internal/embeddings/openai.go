@@ -0,0 +1,100 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// defaultOpenAIModel matches the ivfflat index in setup.sql
+	defaultOpenAIModel = string(openai.AdaEmbeddingV2)
+	defaultBatchSize   = 100
+)
+
+// OpenAIEmbedder calls the OpenAI /embeddings endpoint, or any server that
+// implements it, sending up to batchSize texts per request
+type OpenAIEmbedder struct {
+	client     *openai.Client
+	model      string
+	dimensions int
+	batchSize  int
+}
+
+// NewOpenAIEmbedder uses the OpenAI API, defaulting to text-embedding-ada-002.
+// dimensions is only sent when positive.
+func NewOpenAIEmbedder(apiKey string, model string, dimensions int, batchSize int) *OpenAIEmbedder {
+	return newOpenAIEmbedder(openai.DefaultConfig(apiKey), model, dimensions, batchSize)
+}
+
+func NewOpenAICompatibleEmbedder(baseURL string, apiKey string, model string, dimensions int, batchSize int) *OpenAIEmbedder {
+	cfg := openai.DefaultConfig(apiKey)
+	cfg.BaseURL = strings.TrimSuffix(baseURL, "/")
+	return newOpenAIEmbedder(cfg, model, dimensions, batchSize)
+}
+
+func newOpenAIEmbedder(cfg openai.ClientConfig, model string, dimensions int, batchSize int) *OpenAIEmbedder {
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	return &OpenAIEmbedder{
+		client:     openai.NewClientWithConfig(cfg),
+		model:      model,
+		dimensions: dimensions,
+		batchSize:  batchSize,
+	}
+}
+
+// Model names the vectors' model and, when a size was asked for, their size,
+// as in text-embedding-3-small@512. Models such as text-embedding-3-small
+// shorten vectors on request, and shortened vectors can't be compared with
+// full-size ones, so each size is indexed and searched as its own model.
+func (e *OpenAIEmbedder) Model() string {
+	if e.dimensions > 0 {
+		return fmt.Sprintf("%s@%d", e.model, e.dimensions)
+	}
+	return e.model
+}
+
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += e.batchSize {
+		batch := texts[start:min(start+e.batchSize, len(texts))]
+		embedded, err := e.embedBatch(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, embedded...)
+	}
+	return vectors, nil
+}
+
+// embedBatch sends one request. The response carries each vector's index, so
+// vectors are placed by index rather than by their order in the response.
+func (e *OpenAIEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	resp, err := e.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Model:      openai.EmbeddingModel(e.model),
+		Input:      texts,
+		Dimensions: e.dimensions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding response has %d vectors for %d texts", len(resp.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, data := range resp.Data {
+		if data.Index < 0 || data.Index >= len(texts) || vectors[data.Index] != nil {
+			return nil, fmt.Errorf("embedding response has an unexpected index %d", data.Index)
+		}
+		vectors[data.Index] = data.Embedding
+	}
+	return vectors, nil
+}
@@ -0,0 +1,160 @@
+package ingest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+	"jamesfarrell.me/youtube-to-text/internal/storage/postgres"
+)
+
+// maxFeedSize bounds how much of a feed is read; long-running podcasts
+// publish feeds of several megabytes
+const maxFeedSize = 32 * 1024 * 1024
+
+// ImportFeed fetches a podcast RSS or Atom feed and inserts a "Video" row for
+// every episode not already present, all linked to one collection
+func ImportFeed(ctx context.Context, repo *postgres.CollectionRepository, url string, isSearchable bool) (*models.CollectionResponse, error) {
+	feed, err := FetchFeed(ctx, url)
+	if err != nil {
+		return nil, &FetchError{Err: err}
+	}
+	return repo.CreateWithVideos(ctx, feed, isSearchable)
+}
+
+// FetchFeed downloads a feed and lists its episodes with their enclosure URLs
+func FetchFeed(ctx context.Context, url string) (*models.Playlist, error) {
+	url = strings.TrimSpace(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating feed request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching feed: unexpected status code: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize))
+	if err != nil {
+		return nil, fmt.Errorf("error reading feed: %w", err)
+	}
+
+	feed, err := ParseFeed(data)
+	if err != nil {
+		return nil, err
+	}
+	feed.URL = url
+	return feed, nil
+}
+
+type rssFeed struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			Title     string `xml:"title"`
+			GUID      string `xml:"guid"`
+			Enclosure struct {
+				URL  string `xml:"url,attr"`
+				Type string `xml:"type,attr"`
+			} `xml:"enclosure"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type atomFeed struct {
+	ID      string `xml:"id"`
+	Title   string `xml:"title"`
+	Entries []struct {
+		ID    string `xml:"id"`
+		Title string `xml:"title"`
+		Links []struct {
+			Rel  string `xml:"rel,attr"`
+			Href string `xml:"href,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+// ParseFeed reads an RSS 2.0 or Atom feed. Episodes without an audio or video
+// enclosure are skipped.
+func ParseFeed(data []byte) (*models.Playlist, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("error parsing feed: %w", err)
+	}
+
+	feed := &models.Playlist{Kind: models.CollectionFeed}
+	switch root.XMLName.Local {
+	case "rss":
+		var rss rssFeed
+		if err := xml.Unmarshal(data, &rss); err != nil {
+			return nil, fmt.Errorf("error parsing RSS feed: %w", err)
+		}
+		feed.Title = strings.TrimSpace(rss.Channel.Title)
+		for _, item := range rss.Channel.Items {
+			if entry, ok := feedEntry(item.GUID, item.Title, item.Enclosure.URL, item.Enclosure.Type); ok {
+				feed.Entries = append(feed.Entries, entry)
+			}
+		}
+	case "feed":
+		var atom atomFeed
+		if err := xml.Unmarshal(data, &atom); err != nil {
+			return nil, fmt.Errorf("error parsing Atom feed: %w", err)
+		}
+		feed.ID = strings.TrimSpace(atom.ID)
+		feed.Title = strings.TrimSpace(atom.Title)
+		for _, e := range atom.Entries {
+			for _, link := range e.Links {
+				if link.Rel != "enclosure" {
+					continue
+				}
+				if entry, ok := feedEntry(e.ID, e.Title, link.Href, link.Type); ok {
+					feed.Entries = append(feed.Entries, entry)
+					break
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("URL is not an RSS or Atom feed")
+	}
+	return feed, nil
+}
+
+// feedEntry builds an episode from its enclosure. The entry ID is a hash of
+// the episode's GUID, falling back to the enclosure URL, so it stays stable
+// when the feed is imported again and fits in the slug column.
+func feedEntry(guid, title, enclosureURL, mediaType string) (models.PlaylistEntry, bool) {
+	enclosureURL = strings.TrimSpace(enclosureURL)
+	if enclosureURL == "" {
+		return models.PlaylistEntry{}, false
+	}
+	if mediaType != "" && !strings.HasPrefix(mediaType, "audio/") && !strings.HasPrefix(mediaType, "video/") {
+		return models.PlaylistEntry{}, false
+	}
+
+	key := strings.TrimSpace(guid)
+	if key == "" {
+		key = enclosureURL
+	}
+	sum := sha256.Sum256([]byte(key))
+
+	return models.PlaylistEntry{
+		ID:    "rss-" + hex.EncodeToString(sum[:8]),
+		URL:   enclosureURL,
+		Title: strings.TrimSpace(title),
+	}, true
+}
@@ -0,0 +1,109 @@
+package ingest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+const rssFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">
+  <channel>
+    <title>Example Podcast</title>
+    <item>
+      <title>Episode 2</title>
+      <guid isPermaLink="false">episode-2</guid>
+      <enclosure url="https://cdn.example.com/ep2.mp3" length="1234" type="audio/mpeg"/>
+    </item>
+    <item>
+      <title>Show notes only</title>
+      <guid>notes</guid>
+    </item>
+    <item>
+      <title>Episode 1</title>
+      <enclosure url="https://cdn.example.com/ep1.m4a" type="audio/x-m4a"/>
+    </item>
+    <item>
+      <title>Transcript PDF</title>
+      <enclosure url="https://cdn.example.com/ep1.pdf" type="application/pdf"/>
+    </item>
+  </channel>
+</rss>`
+
+const atomFixture = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <id>urn:example:feed</id>
+  <title>Example Atom Podcast</title>
+  <entry>
+    <id>urn:example:episode-1</id>
+    <title>First episode</title>
+    <link rel="alternate" href="https://example.com/episodes/1"/>
+    <link rel="enclosure" href="https://cdn.example.com/atom1.mp3" type="audio/mpeg"/>
+  </entry>
+</feed>`
+
+func serveFeed(t *testing.T, body string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/feed.xml"
+}
+
+func TestFetchFeedRSS(t *testing.T) {
+	url := serveFeed(t, rssFixture)
+
+	feed, err := FetchFeed(context.Background(), url)
+	if err != nil {
+		t.Fatalf("FetchFeed() error = %v", err)
+	}
+
+	if feed.Title != "Example Podcast" || feed.Kind != models.CollectionFeed || feed.URL != url {
+		t.Errorf("FetchFeed() = %q %q %q, want feed title, kind and URL", feed.Title, feed.Kind, feed.URL)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("FetchFeed() returned %d entries, want 2: %+v", len(feed.Entries), feed.Entries)
+	}
+
+	first := feed.Entries[0]
+	if first.URL != "https://cdn.example.com/ep2.mp3" || first.Title != "Episode 2" {
+		t.Errorf("entry = %+v, want episode 2 enclosure", first)
+	}
+	if want, _ := feedEntry("episode-2", "", "https://cdn.example.com/other.mp3", ""); first.ID != want.ID {
+		t.Errorf("entry ID = %q, want ID derived from GUID %q", first.ID, want.ID)
+	}
+	if feed.Entries[1].ID == first.ID {
+		t.Errorf("entries share ID %q", first.ID)
+	}
+}
+
+func TestFetchFeedAtom(t *testing.T) {
+	feed, err := FetchFeed(context.Background(), serveFeed(t, atomFixture))
+	if err != nil {
+		t.Fatalf("FetchFeed() error = %v", err)
+	}
+
+	if feed.Title != "Example Atom Podcast" || len(feed.Entries) != 1 {
+		t.Fatalf("FetchFeed() = %+v, want one Atom entry", feed)
+	}
+	if entry := feed.Entries[0]; entry.URL != "https://cdn.example.com/atom1.mp3" || entry.Title != "First episode" {
+		t.Errorf("entry = %+v, want enclosure link", entry)
+	}
+}
+
+func TestFetchFeedErrors(t *testing.T) {
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	if _, err := FetchFeed(context.Background(), notFound.URL); err == nil {
+		t.Error("FetchFeed() on 404 returned no error")
+	}
+
+	if _, err := FetchFeed(context.Background(), serveFeed(t, "<html><body>not a feed</body></html>")); err == nil {
+		t.Error("FetchFeed() on HTML returned no error")
+	}
+}
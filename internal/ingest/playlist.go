@@ -0,0 +1,119 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+	"jamesfarrell.me/youtube-to-text/internal/storage/postgres"
+)
+
+// channelPattern matches channel URLs that don't already point at a tab
+var channelPattern = regexp.MustCompile(`youtube\.com/(@[^/?#]+|channel/[^/?#]+|c/[^/?#]+|user/[^/?#]+)/?$`)
+
+// FetchError means the playlist or feed to import couldn't be fetched
+type FetchError struct {
+	Err error
+}
+
+func (e *FetchError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// ImportPlaylist expands a playlist or channel URL and inserts a "Video" row
+// for every entry not already present, all linked to one collection
+func ImportPlaylist(ctx context.Context, repo *postgres.CollectionRepository, url string, isSearchable bool) (*models.CollectionResponse, error) {
+	playlist, err := ExpandPlaylist(ctx, url)
+	if err != nil {
+		return nil, &FetchError{Err: err}
+	}
+	return repo.CreateWithVideos(ctx, playlist, isSearchable)
+}
+
+// ExpandPlaylist lists the videos of a playlist or channel with yt-dlp's
+// flat-playlist mode, which doesn't visit each video
+func ExpandPlaylist(ctx context.Context, url string) (*models.Playlist, error) {
+	return expandPlaylist(ctx, url)
+}
+
+func expandPlaylist(ctx context.Context, url string, extraArgs ...string) (*models.Playlist, error) {
+	url, kind := NormalizePlaylistURL(url)
+
+	args := append([]string{"--flat-playlist", "-J"}, extraArgs...)
+	cmd := exec.CommandContext(ctx, "yt-dlp", append(args, url)...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error expanding playlist: %w, output: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	playlist, err := parseFlatPlaylist(output)
+	if err != nil {
+		return nil, err
+	}
+	playlist.URL = url
+	playlist.Kind = kind
+	return playlist, nil
+}
+
+// NormalizePlaylistURL points bare channel URLs at their videos tab, since
+// yt-dlp otherwise lists the channel's tabs instead of its videos
+func NormalizePlaylistURL(url string) (string, string) {
+	url = strings.TrimSpace(url)
+	if channelPattern.MatchString(url) {
+		return strings.TrimSuffix(url, "/") + "/videos", models.CollectionChannel
+	}
+	if strings.Contains(url, "list=") {
+		return url, models.CollectionPlaylist
+	}
+	if strings.Contains(url, "/@") || strings.Contains(url, "/channel/") || strings.Contains(url, "/c/") || strings.Contains(url, "/user/") {
+		return url, models.CollectionChannel
+	}
+	return url, models.CollectionPlaylist
+}
+
+type flatPlaylist struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Type    string `json:"_type"`
+	Entries []struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+		IEKey string `json:"ie_key"`
+	} `json:"entries"`
+}
+
+func parseFlatPlaylist(data []byte) (*models.Playlist, error) {
+	var flat flatPlaylist
+	if err := json.Unmarshal(data, &flat); err != nil {
+		return nil, fmt.Errorf("error parsing playlist: %w", err)
+	}
+	if flat.Type != "playlist" {
+		return nil, fmt.Errorf("URL is not a playlist or channel")
+	}
+
+	playlist := &models.Playlist{ID: flat.ID, Title: flat.Title}
+	for _, entry := range flat.Entries {
+		// Skip nested tabs or playlists and anything that isn't a YouTube video
+		if entry.ID == "" || (entry.IEKey != "" && entry.IEKey != "Youtube") {
+			continue
+		}
+		playlist.Entries = append(playlist.Entries, models.PlaylistEntry{
+			ID:    entry.ID,
+			URL:   "https://www.youtube.com/watch?v=" + entry.ID,
+			Title: entry.Title,
+		})
+	}
+	return playlist, nil
+}
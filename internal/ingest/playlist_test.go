@@ -0,0 +1,54 @@
+package ingest
+
+import "testing"
+
+const flatPlaylistJSON = `{
+	"_type": "playlist",
+	"id": "PL123",
+	"title": "GopherCon 2024",
+	"entries": [
+		{"_type": "url", "ie_key": "Youtube", "id": "abc123", "url": "https://www.youtube.com/watch?v=abc123", "title": "Keynote"},
+		{"_type": "url", "ie_key": "YoutubeTab", "id": "UCxyz", "url": "https://www.youtube.com/channel/UCxyz", "title": "Shorts"},
+		{"_type": "url", "ie_key": "Youtube", "id": "def456", "url": "https://www.youtube.com/shorts/def456", "title": "Lightning talk"}
+	]
+}`
+
+func TestParseFlatPlaylist(t *testing.T) {
+	playlist, err := parseFlatPlaylist([]byte(flatPlaylistJSON))
+	if err != nil {
+		t.Fatalf("parseFlatPlaylist() error = %v", err)
+	}
+	if playlist.ID != "PL123" || playlist.Title != "GopherCon 2024" {
+		t.Errorf("unexpected playlist: %+v", playlist)
+	}
+	if len(playlist.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(playlist.Entries), playlist.Entries)
+	}
+	if playlist.Entries[1].URL != "https://www.youtube.com/watch?v=def456" {
+		t.Errorf("entry URL = %s, want a watch URL", playlist.Entries[1].URL)
+	}
+
+	if _, err := parseFlatPlaylist([]byte(`{"_type": "video", "id": "abc123"}`)); err == nil {
+		t.Error("parseFlatPlaylist() expected error for a single video")
+	}
+}
+
+func TestNormalizePlaylistURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		wantURL  string
+		wantKind string
+	}{
+		{"https://www.youtube.com/@GopherAcademy", "https://www.youtube.com/@GopherAcademy/videos", "channel"},
+		{"https://www.youtube.com/channel/UC123/", "https://www.youtube.com/channel/UC123/videos", "channel"},
+		{"https://www.youtube.com/@GopherAcademy/streams", "https://www.youtube.com/@GopherAcademy/streams", "channel"},
+		{"https://www.youtube.com/playlist?list=PL123", "https://www.youtube.com/playlist?list=PL123", "playlist"},
+	}
+
+	for _, tt := range tests {
+		gotURL, gotKind := NormalizePlaylistURL(tt.url)
+		if gotURL != tt.wantURL || gotKind != tt.wantKind {
+			t.Errorf("NormalizePlaylistURL(%q) = %q, %q; want %q, %q", tt.url, gotURL, gotKind, tt.wantURL, tt.wantKind)
+		}
+	}
+}
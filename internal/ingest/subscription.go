@@ -0,0 +1,137 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+	"jamesfarrell.me/youtube-to-text/internal/storage/postgres"
+)
+
+const (
+	// subscriptionCheckInterval is how often the poller looks for subscriptions
+	// whose own poll interval has passed
+	subscriptionCheckInterval = time.Minute
+	// recentChannelEntries bounds how much of a channel is listed on each poll;
+	// channels list newest first, so new uploads are always near the top
+	recentChannelEntries = 50
+)
+
+// Poller checks subscribed channels and playlists for new videos and inserts
+// them like any other video, so the transcription queue picks them up
+type Poller struct {
+	subscriptions *postgres.SubscriptionRepository
+	videos        *postgres.VideoRepository
+}
+
+func NewPoller(subscriptions *postgres.SubscriptionRepository, videos *postgres.VideoRepository) *Poller {
+	return &Poller{subscriptions: subscriptions, videos: videos}
+}
+
+// Run polls due subscriptions until the context is cancelled. Subscriptions
+// are claimed in the database, so several pollers can run at once.
+func (p *Poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(subscriptionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		p.pollDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Poller) pollDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		subscription, err := p.subscriptions.ClaimDue(ctx)
+		if err != nil {
+			fmt.Printf("Failed to claim subscription: %v\n", err)
+			return
+		}
+		if subscription == nil {
+			return
+		}
+
+		added, err := p.Poll(ctx, subscription)
+		if err != nil {
+			// The claim already moved lastPolledAt, so this is retried next interval
+			fmt.Printf("Failed to poll subscription %s: %v\n", subscription.SourceURL, err)
+			continue
+		}
+		if added > 0 {
+			fmt.Printf("Added %d new videos from %s\n", added, subscription.SourceURL)
+		}
+	}
+}
+
+// Poll inserts the videos published since the subscription's last-seen video
+// and records the newest one. The first poll only records the newest video,
+// so subscribing doesn't import the channel's back catalogue.
+func (p *Poller) Poll(ctx context.Context, subscription *models.Subscription) (int, error) {
+	newestFirst := subscription.Kind == models.CollectionChannel
+
+	var args []string
+	if newestFirst {
+		args = []string{"--playlist-end", strconv.Itoa(recentChannelEntries)}
+	}
+	playlist, err := expandPlaylist(ctx, subscription.SourceURL, args...)
+	if err != nil {
+		return 0, err
+	}
+	if len(playlist.Entries) == 0 {
+		return 0, nil
+	}
+
+	var lastSeenID string
+	if subscription.LastSeenVideoID != nil {
+		lastSeenID = *subscription.LastSeenVideoID
+	}
+	entries, newestID := newEntries(playlist.Entries, lastSeenID, newestFirst)
+
+	added := 0
+	for _, entry := range entries {
+		// Skip videos imported some other way, or left over from a poll that
+		// failed part way through
+		created, err := p.videos.CreateIfNew(ctx, entry.URL, entry.ID, subscription.IsSearchable)
+		if err != nil {
+			return added, fmt.Errorf("failed to add video %s: %w", entry.ID, err)
+		}
+		if created {
+			added++
+		}
+	}
+
+	return added, p.subscriptions.MarkSeen(ctx, subscription.ID, newestID, playlist.Title)
+}
+
+// newEntries returns the entries after lastSeenID, oldest first, along with
+// the ID of the newest entry. Without a last-seen video there is nothing to
+// compare against, so no entries are new. When the last-seen video is no
+// longer listed every entry is returned and existing videos are skipped later.
+func newEntries(entries []models.PlaylistEntry, lastSeenID string, newestFirst bool) ([]models.PlaylistEntry, string) {
+	if len(entries) == 0 {
+		return nil, lastSeenID
+	}
+
+	ordered := slices.Clone(entries)
+	if newestFirst {
+		slices.Reverse(ordered)
+	}
+	newestID := ordered[len(ordered)-1].ID
+
+	if lastSeenID == "" {
+		return nil, newestID
+	}
+
+	i := slices.IndexFunc(ordered, func(entry models.PlaylistEntry) bool {
+		return entry.ID == lastSeenID
+	})
+	return ordered[i+1:], newestID
+}
@@ -0,0 +1,53 @@
+package ingest
+
+import (
+	"reflect"
+	"testing"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+func entryIDs(entries []models.PlaylistEntry) []string {
+	var ids []string
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
+
+func TestNewEntries(t *testing.T) {
+	var entries []models.PlaylistEntry
+	for _, id := range []string{"a", "b", "c", "d"} {
+		entries = append(entries, models.PlaylistEntry{ID: id})
+	}
+
+	tests := []struct {
+		name        string
+		lastSeenID  string
+		newestFirst bool
+		wantIDs     []string
+		wantNewest  string
+	}{
+		{name: "first poll sets baseline", lastSeenID: "", newestFirst: true, wantIDs: nil, wantNewest: "a"},
+		{name: "channel newest first", lastSeenID: "c", newestFirst: true, wantIDs: []string{"b", "a"}, wantNewest: "a"},
+		{name: "playlist appended at end", lastSeenID: "b", newestFirst: false, wantIDs: []string{"c", "d"}, wantNewest: "d"},
+		{name: "nothing new", lastSeenID: "a", newestFirst: true, wantIDs: nil, wantNewest: "a"},
+		{name: "last seen no longer listed", lastSeenID: "z", newestFirst: true, wantIDs: []string{"d", "c", "b", "a"}, wantNewest: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, newest := newEntries(entries, tt.lastSeenID, tt.newestFirst)
+			if ids := entryIDs(got); !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("newEntries() = %v, want %v", ids, tt.wantIDs)
+			}
+			if newest != tt.wantNewest {
+				t.Errorf("newEntries() newest = %q, want %q", newest, tt.wantNewest)
+			}
+		})
+	}
+
+	if _, newest := newEntries(nil, "a", true); newest != "a" {
+		t.Errorf("newEntries(nil) newest = %q, want last seen ID kept", newest)
+	}
+}
@@ -0,0 +1,46 @@
+package search
+
+import (
+	"sort"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+// rrfK damps the difference between the top ranks, as in the original
+// reciprocal rank fusion paper, so neither ranking dominates on its own
+const rrfK = 60
+
+// fuse merges rankings with reciprocal rank fusion: a chunk scores
+// 1/(rrfK + rank) in every ranking it appears in, and the scores add up.
+// Ranks are compared rather than the scores behind them, which sit on
+// different scales. A chunk found by several rankings keeps the first copy,
+// with the best keyword rank seen.
+func fuse(limit int, rankings ...[]models.SearchResult) []models.SearchResult {
+	fused := []models.SearchResult{}
+	index := map[int64]int{}
+	for _, ranking := range rankings {
+		for rank, result := range ranking {
+			score := 1 / float64(rrfK+rank+1)
+			i, ok := index[result.ChunkID]
+			if !ok {
+				index[result.ChunkID] = len(fused)
+				result.Score = score
+				fused = append(fused, result)
+				continue
+			}
+			fused[i].Score += score
+			fused[i].KeywordRank = max(fused[i].KeywordRank, result.KeywordRank)
+		}
+	}
+
+	sort.SliceStable(fused, func(i, j int) bool {
+		if fused[i].Score != fused[j].Score {
+			return fused[i].Score > fused[j].Score
+		}
+		return fused[i].Similarity > fused[j].Similarity
+	})
+	if len(fused) > limit {
+		fused = fused[:limit]
+	}
+	return fused
+}
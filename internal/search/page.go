@@ -0,0 +1,83 @@
+package search
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+// cursor is the position of the next page. It carries a fingerprint of the
+// request so it can't be replayed against a different search.
+type cursor struct {
+	Offset  int    `json:"o"`
+	Request uint64 `json:"r"`
+}
+
+// fingerprint hashes everything about a request except its cursor
+func fingerprint(req models.SearchRequest) uint64 {
+	req.Cursor = ""
+	data, _ := json.Marshal(req)
+	h := fnv.New64a()
+	h.Write(data)
+	return h.Sum64()
+}
+
+func encodeCursor(req models.SearchRequest, offset int) string {
+	data, _ := json.Marshal(cursor{Offset: offset, Request: fingerprint(req)})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns where the request's page starts, 0 without a cursor
+func decodeCursor(req models.SearchRequest) (int, error) {
+	if req.Cursor == "" {
+		return 0, nil
+	}
+
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(req.Cursor)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil || c.Offset < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	if c.Request != fingerprint(req) {
+		return 0, fmt.Errorf("cursor belongs to a different search: repeat the request it came from with only the cursor added")
+	}
+	return c.Offset, nil
+}
+
+// page returns up to limit items from offset, and whether more follow
+func page[T any](items []T, offset int, limit int) ([]T, bool) {
+	if offset >= len(items) {
+		return []T{}, false
+	}
+	end := min(offset+limit, len(items))
+	return items[offset:end], end < len(items)
+}
+
+// groupByVideo groups ranked chunks by video, keeping the best perVideo
+// chunks of each. Videos are ordered by their best chunk.
+func groupByVideo(ranked []models.SearchResult, perVideo int) []models.VideoSearchResults {
+	groups := []models.VideoSearchResults{}
+	index := map[string]int{}
+	for _, result := range ranked {
+		i, ok := index[result.VideoID]
+		if !ok {
+			i = len(groups)
+			index[result.VideoID] = i
+			groups = append(groups, models.VideoSearchResults{
+				VideoID: result.VideoID,
+				Title:   result.Title,
+				Score:   result.Score,
+			})
+		}
+		if len(groups[i].Results) < perVideo {
+			groups[i].Results = append(groups[i].Results, result)
+		}
+	}
+	return groups
+}
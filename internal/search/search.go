@@ -0,0 +1,253 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"jamesfarrell.me/youtube-to-text/internal/embeddings"
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+	"jamesfarrell.me/youtube-to-text/internal/storage/postgres"
+)
+
+const (
+	DefaultLimit    = 10
+	MaxLimit        = 50
+	DefaultPerVideo = 3
+	MaxPerVideo     = 10
+
+	// maxDepth bounds how many chunks are ranked for one page, so paging
+	// stops after the first maxDepth results and the response says so
+	maxDepth = 500
+
+	// hybridCandidates is how many results each ranking contributes to the
+	// fusion for every result returned, so chunks ranked well by only one
+	// of them still get a chance
+	hybridCandidates = 3
+)
+
+// EmbeddingError means the query could not be embedded; the embedding
+// provider failed rather than the request or the database
+type EmbeddingError struct {
+	Err error
+}
+
+func (e *EmbeddingError) Error() string {
+	return fmt.Sprintf("failed to embed query: %v", e.Err)
+}
+
+func (e *EmbeddingError) Unwrap() error {
+	return e.Err
+}
+
+// Searcher finds the transcript chunks that match a query by meaning, by
+// their words, or both
+type Searcher struct {
+	repo     *postgres.SearchRepository
+	embedder embeddings.Embedder
+}
+
+func NewSearcher(repo *postgres.SearchRepository, embedder embeddings.Embedder) *Searcher {
+	return &Searcher{repo: repo, embedder: embedder}
+}
+
+// Model is the embedding model searches compare vectors from
+func (s *Searcher) Model() string {
+	return s.embedder.Model()
+}
+
+// Normalize trims the query and fills in the default mode, limit and chunks
+// per video. It returns an error for a request that can't be run, including
+// one whose cursor came from a different search.
+func Normalize(req *models.SearchRequest) error {
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Query == "" {
+		return fmt.Errorf("query is required")
+	}
+
+	switch req.Mode {
+	case "":
+		req.Mode = models.SearchModeHybrid
+	case models.SearchModeSemantic, models.SearchModeKeyword, models.SearchModeHybrid:
+	default:
+		return fmt.Errorf("invalid mode %q: use semantic, keyword or hybrid", req.Mode)
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = DefaultLimit
+	}
+	if req.Limit > MaxLimit {
+		req.Limit = MaxLimit
+	}
+
+	if req.GroupByVideo {
+		if req.PerVideo <= 0 {
+			req.PerVideo = DefaultPerVideo
+		}
+		req.PerVideo = min(req.PerVideo, MaxPerVideo)
+	} else {
+		req.PerVideo = 0
+	}
+
+	if err := validateFilter(req.SearchFilter, req.Mode); err != nil {
+		return err
+	}
+	_, err := decodeCursor(*req)
+	return err
+}
+
+func validateFilter(filter models.SearchFilter, mode string) error {
+	for _, date := range []string{filter.UploadedFrom, filter.UploadedTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return fmt.Errorf("invalid upload date %q: use YYYY-MM-DD", date)
+		}
+	}
+	if filter.MinSimilarity < -1 || filter.MinSimilarity > 1 {
+		return fmt.Errorf("minSimilarity must be between -1 and 1")
+	}
+	if filter.MinSimilarity != 0 && mode == models.SearchModeKeyword {
+		return fmt.Errorf("minSimilarity needs the semantic or hybrid mode")
+	}
+	return nil
+}
+
+// Search runs a normalized request and returns one page of results, each
+// pointed at where its words are spoken
+func (s *Searcher) Search(ctx context.Context, req models.SearchRequest) (*models.SearchResponse, error) {
+	offset, err := decodeCursor(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// The query is embedded once however many times the chunks are ranked
+	var embedding []float32
+	if req.Mode != models.SearchModeKeyword {
+		if embedding, err = s.embed(ctx, req.Query); err != nil {
+			return nil, err
+		}
+	}
+
+	// Rank one result, or one video, past the page to tell whether another
+	// page follows
+	ranked, truncated, err := rankDeep(func(depth int) ([]models.SearchResult, error) {
+		return s.rank(ctx, req, embedding, depth)
+	}, offset+req.Limit+1, req.PerVideo)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ranked {
+		ranked[i].LocateMatch(req.Query)
+	}
+
+	response := &models.SearchResponse{Results: []models.SearchResult{}, Mode: req.Mode, Model: s.Model(), Truncated: truncated}
+	var more bool
+	if req.GroupByVideo {
+		response.Videos, more = page(groupByVideo(ranked, req.PerVideo), offset, req.Limit)
+	} else {
+		response.Results, more = page(ranked, offset, req.Limit)
+	}
+	if more {
+		response.NextCursor = encodeCursor(req, offset+req.Limit)
+	}
+	return response, nil
+}
+
+// rankDeep ranks enough chunks to hold want results or, when grouping
+// perVideo chunks by video, want videos. A few videos can hold most of the
+// best chunks, so grouped searches rank deeper until they find enough videos
+// or run out of chunks. It reports whether it stopped at maxDepth first.
+func rankDeep(rank func(depth int) ([]models.SearchResult, error), want int, perVideo int) ([]models.SearchResult, bool, error) {
+	depth := want * max(perVideo, 1)
+	for {
+		depth = min(depth, maxDepth)
+		ranked, err := rank(depth)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(ranked) < depth {
+			return ranked, false, nil
+		}
+
+		found := len(ranked)
+		if perVideo > 0 {
+			found = countVideos(ranked)
+		}
+		if found >= want {
+			return ranked, false, nil
+		}
+		if depth == maxDepth {
+			return ranked, true, nil
+		}
+		depth *= 2
+	}
+}
+
+func countVideos(ranked []models.SearchResult) int {
+	videos := map[string]bool{}
+	for _, result := range ranked {
+		videos[result.VideoID] = true
+	}
+	return len(videos)
+}
+
+// rank returns the best limit chunks for the request's mode, best first.
+// The embedding is unused by keyword searches.
+func (s *Searcher) rank(ctx context.Context, req models.SearchRequest, embedding []float32, limit int) ([]models.SearchResult, error) {
+	switch req.Mode {
+	case models.SearchModeSemantic:
+		return s.semantic(ctx, req, embedding, limit)
+	case models.SearchModeKeyword:
+		return s.keyword(ctx, req, limit)
+	default:
+		return s.hybrid(ctx, req, embedding, limit)
+	}
+}
+
+func (s *Searcher) semantic(ctx context.Context, req models.SearchRequest, embedding []float32, limit int) ([]models.SearchResult, error) {
+	results, err := s.repo.SemanticSearch(ctx, embedding, s.Model(), req.SearchFilter, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Score = results[i].Similarity
+	}
+	return results, nil
+}
+
+// keyword needs no embedding, so it keeps working when the embedding
+// provider is down
+func (s *Searcher) keyword(ctx context.Context, req models.SearchRequest, limit int) ([]models.SearchResult, error) {
+	results, err := s.repo.KeywordSearch(ctx, req.Query, nil, s.Model(), req.SearchFilter, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Score = results[i].KeywordRank
+	}
+	return results, nil
+}
+
+func (s *Searcher) hybrid(ctx context.Context, req models.SearchRequest, embedding []float32, limit int) ([]models.SearchResult, error) {
+	candidates := limit * hybridCandidates
+	semantic, err := s.repo.SemanticSearch(ctx, embedding, s.Model(), req.SearchFilter, candidates)
+	if err != nil {
+		return nil, err
+	}
+	keyword, err := s.repo.KeywordSearch(ctx, req.Query, embedding, s.Model(), req.SearchFilter, candidates)
+	if err != nil {
+		return nil, err
+	}
+	return fuse(limit, semantic, keyword), nil
+}
+
+func (s *Searcher) embed(ctx context.Context, query string) ([]float32, error) {
+	embedding, err := embeddings.Embed(ctx, s.embedder, query)
+	if err != nil {
+		return nil, &EmbeddingError{Err: err}
+	}
+	return embedding, nil
+}
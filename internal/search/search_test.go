@@ -0,0 +1,215 @@
+package search
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+func chunkIDs(results []models.SearchResult) []int64 {
+	ids := []int64{}
+	for _, result := range results {
+		ids = append(ids, result.ChunkID)
+	}
+	return ids
+}
+
+func TestFuse(t *testing.T) {
+	semantic := []models.SearchResult{
+		{ChunkID: 1, Similarity: 0.90},
+		{ChunkID: 2, Similarity: 0.85},
+		{ChunkID: 3, Similarity: 0.80},
+	}
+	keyword := []models.SearchResult{
+		{ChunkID: 3, Similarity: 0.80, KeywordRank: 0.6},
+		{ChunkID: 4, Similarity: 0.40, KeywordRank: 0.5},
+	}
+
+	fused := fuse(10, semantic, keyword)
+	// Chunk 3 is found by both rankings, so it beats chunks only one of them
+	// ranked higher; 2 and 4 tie on rank and similarity breaks the tie
+	if got, want := chunkIDs(fused), []int64{3, 1, 2, 4}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("fuse() order = %v, want %v", got, want)
+	}
+	if fused[0].KeywordRank != 0.6 {
+		t.Errorf("merged chunk keyword rank = %v, want 0.6", fused[0].KeywordRank)
+	}
+	if want := 1.0/63 + 1.0/61; fused[0].Score != want {
+		t.Errorf("merged chunk score = %v, want %v", fused[0].Score, want)
+	}
+
+	if got := fuse(2, semantic, keyword); len(got) != 2 {
+		t.Errorf("fuse() with limit 2 returned %d results", len(got))
+	}
+	if got := fuse(5); len(got) != 0 {
+		t.Errorf("fuse() without rankings = %v, want none", got)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	req := models.SearchRequest{Query: "  ACME-42 pricing ", Limit: 500}
+	if err := Normalize(&req); err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	want := models.SearchRequest{Query: "ACME-42 pricing", Limit: MaxLimit, Mode: models.SearchModeHybrid}
+	if !reflect.DeepEqual(req, want) {
+		t.Errorf("Normalize() = %+v, want %+v", req, want)
+	}
+
+	req = models.SearchRequest{Query: "pricing", Mode: models.SearchModeKeyword}
+	if err := Normalize(&req); err != nil || req.Limit != DefaultLimit || req.Mode != models.SearchModeKeyword {
+		t.Errorf("Normalize() = %+v, %v", req, err)
+	}
+
+	req = models.SearchRequest{Query: "pricing", GroupByVideo: true, PerVideo: 50}
+	if err := Normalize(&req); err != nil || req.PerVideo != MaxPerVideo {
+		t.Errorf("Normalize() = %+v, %v, want perVideo capped at %d", req, err, MaxPerVideo)
+	}
+	req = models.SearchRequest{Query: "pricing", GroupByVideo: true}
+	if err := Normalize(&req); err != nil || req.PerVideo != DefaultPerVideo {
+		t.Errorf("Normalize() = %+v, %v, want the default perVideo", req, err)
+	}
+
+	bad := []models.SearchRequest{
+		{Query: "   "},
+		{Query: "pricing", Mode: "fuzzy"},
+		{Query: "pricing", SearchFilter: models.SearchFilter{UploadedFrom: "31/01/2024"}},
+		{Query: "pricing", SearchFilter: models.SearchFilter{MinSimilarity: 1.5}},
+		{Query: "pricing", Mode: models.SearchModeKeyword, SearchFilter: models.SearchFilter{MinSimilarity: 0.8}},
+		{Query: "pricing", Cursor: "not a cursor"},
+	}
+	for _, req := range bad {
+		if err := Normalize(&req); err == nil {
+			t.Errorf("Normalize(%+v) expected an error", req)
+		}
+	}
+}
+
+func TestCursor(t *testing.T) {
+	req := models.SearchRequest{Query: "pricing", SearchFilter: models.SearchFilter{ChannelID: "UC123"}}
+	if err := Normalize(&req); err != nil {
+		t.Fatal(err)
+	}
+
+	next := req
+	next.Cursor = encodeCursor(req, 20)
+	if err := Normalize(&next); err != nil {
+		t.Fatalf("Normalize() rejected its own cursor: %v", err)
+	}
+	if offset, err := decodeCursor(next); err != nil || offset != 20 {
+		t.Errorf("decodeCursor() = %d, %v, want 20", offset, err)
+	}
+
+	other := next
+	other.ChannelID = "UC456"
+	if _, err := decodeCursor(other); err == nil {
+		t.Error("decodeCursor() accepted a cursor from a search with another filter")
+	}
+}
+
+func TestPage(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	tests := []struct {
+		offset, limit int
+		want          []int
+		more          bool
+	}{
+		{0, 2, []int{1, 2}, true},
+		{2, 3, []int{3, 4, 5}, false},
+		{4, 3, []int{5}, false},
+		{10, 3, []int{}, false},
+	}
+	for _, tt := range tests {
+		got, more := page(items, tt.offset, tt.limit)
+		if !reflect.DeepEqual(got, tt.want) || more != tt.more {
+			t.Errorf("page(%d, %d) = %v, %v, want %v, %v", tt.offset, tt.limit, got, more, tt.want, tt.more)
+		}
+	}
+}
+
+func TestGroupByVideo(t *testing.T) {
+	ranked := []models.SearchResult{
+		{ChunkID: 1, VideoID: "a", Title: "A", Score: 0.9},
+		{ChunkID: 2, VideoID: "b", Title: "B", Score: 0.8},
+		{ChunkID: 3, VideoID: "a", Score: 0.7},
+		{ChunkID: 4, VideoID: "a", Score: 0.6},
+		{ChunkID: 5, VideoID: "c", Title: "C", Score: 0.5},
+	}
+
+	groups := groupByVideo(ranked, 2)
+	if len(groups) != 3 {
+		t.Fatalf("groupByVideo() = %d videos, want 3", len(groups))
+	}
+	if groups[0].VideoID != "a" || groups[0].Title != "A" || groups[0].Score != 0.9 {
+		t.Errorf("first group = %+v, want video a with its best score", groups[0])
+	}
+	if got := chunkIDs(groups[0].Results); !reflect.DeepEqual(got, []int64{1, 3}) {
+		t.Errorf("video a chunks = %v, want the best 2", got)
+	}
+	if groups[1].VideoID != "b" || groups[2].VideoID != "c" {
+		t.Errorf("videos out of order: %+v", groups)
+	}
+}
+
+// rankedChunks fakes a ranking of n chunks where the first dominant chunks
+// all belong to one video and every later chunk to a video of its own
+func rankedChunks(n int, dominant int) func(depth int) ([]models.SearchResult, error) {
+	return func(depth int) ([]models.SearchResult, error) {
+		var ranked []models.SearchResult
+		for i := 0; i < min(depth, n); i++ {
+			videoID := "dominant"
+			if i >= dominant {
+				videoID = fmt.Sprintf("video-%d", i)
+			}
+			ranked = append(ranked, models.SearchResult{ChunkID: int64(i), VideoID: videoID})
+		}
+		return ranked, nil
+	}
+}
+
+func TestRankDeepWhenOneVideoDominates(t *testing.T) {
+	// perVideo 3 and limit 10 rank 33 chunks at first, 30 of them from one
+	// video, which only makes 4 videos
+	var depths []int
+	rank := rankedChunks(200, 30)
+	ranked, truncated, err := rankDeep(func(depth int) ([]models.SearchResult, error) {
+		depths = append(depths, depth)
+		return rank(depth)
+	}, 11, 3)
+	if err != nil {
+		t.Fatalf("rankDeep() error = %v", err)
+	}
+	if truncated {
+		t.Error("rankDeep() truncated a ranking with enough videos")
+	}
+	if !reflect.DeepEqual(depths, []int{33, 66}) {
+		t.Errorf("rankDeep() ranked depths %v, want [33 66]", depths)
+	}
+
+	videos, more := page(groupByVideo(ranked, 3), 0, 10)
+	if len(videos) != 10 || !more {
+		t.Errorf("page() = %d videos, more %v, want 10 and a next page", len(videos), more)
+	}
+}
+
+func TestRankDeepStops(t *testing.T) {
+	// Candidates run out before enough videos are found
+	ranked, truncated, err := rankDeep(rankedChunks(40, 35), 11, 3)
+	if err != nil || truncated || len(ranked) != 40 {
+		t.Errorf("rankDeep() = %d chunks, truncated %v, %v, want all 40", len(ranked), truncated, err)
+	}
+
+	// One video holds more than maxDepth chunks
+	ranked, truncated, err = rankDeep(rankedChunks(2*maxDepth, maxDepth), 11, 3)
+	if err != nil || !truncated || len(ranked) != maxDepth {
+		t.Errorf("rankDeep() = %d chunks, truncated %v, %v, want %d truncated", len(ranked), truncated, err, maxDepth)
+	}
+
+	// Ungrouped pages past maxDepth can't be ranked either
+	_, truncated, _ = rankDeep(rankedChunks(2*maxDepth, 0), maxDepth+11, 0)
+	if !truncated {
+		t.Error("rankDeep() didn't report a page past maxDepth as truncated")
+	}
+}
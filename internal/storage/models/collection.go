@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Collection kinds
+const (
+	CollectionPlaylist = "playlist"
+	CollectionChannel  = "channel"
+	CollectionFeed     = "feed"
+)
+
+// Collection groups the videos imported from one playlist, channel or feed
+type Collection struct {
+	ID        string    `json:"id"`
+	SourceURL string    `json:"sourceUrl"`
+	Title     string    `json:"title"`
+	Kind      string    `json:"kind"`
+	UserID    string    `json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type CollectionRequest struct {
+	URL          string `json:"url"`
+	IsSearchable bool   `json:"isSearchable"`
+}
+
+type CollectionResponse struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Kind     string   `json:"kind"`
+	Added    int      `json:"added"`
+	Skipped  int      `json:"skipped"`
+	VideoIDs []string `json:"videoIds"`
+}
+
+// PlaylistEntry is one video listed by a playlist or channel, or one episode
+// of a feed
+type PlaylistEntry struct {
+	ID    string
+	URL   string
+	Title string
+}
+
+// Playlist is a playlist, channel or feed expanded into its videos
+type Playlist struct {
+	ID      string
+	Title   string
+	URL     string
+	Kind    string
+	Entries []PlaylistEntry
+}
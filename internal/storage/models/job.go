@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Job statuses for the "TranscriptionJob" queue
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+type Job struct {
+	ID        int64
+	VideoID   string
+	Status    string
+	Attempts  int
+	LastError *string
+	LockedBy  *string
+	LockedAt  *time.Time
+	RunAfter  time.Time
+}
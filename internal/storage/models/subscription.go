@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+const DefaultPollIntervalMinutes = 60
+
+// Subscription is a channel or playlist polled for newly published videos
+type Subscription struct {
+	ID                  string     `json:"id"`
+	SourceURL           string     `json:"sourceUrl"`
+	Kind                string     `json:"kind"`
+	Title               *string    `json:"title,omitempty"`
+	IsSearchable        bool       `json:"isSearchable"`
+	PollIntervalMinutes int        `json:"pollIntervalMinutes"`
+	Active              bool       `json:"active"`
+	LastSeenVideoID     *string    `json:"lastSeenVideoId,omitempty"`
+	LastPolledAt        *time.Time `json:"lastPolledAt,omitempty"`
+	UserID              string     `json:"userId"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	UpdatedAt           time.Time  `json:"updatedAt"`
+}
+
+// SubscriptionRequest creates or updates a subscription. Fields left out of
+// an update keep their current value.
+type SubscriptionRequest struct {
+	URL                 string `json:"url"`
+	IsSearchable        *bool  `json:"isSearchable"`
+	PollIntervalMinutes *int   `json:"pollIntervalMinutes"`
+	Active              *bool  `json:"active"`
+}
@@ -0,0 +1,243 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Transcript is the provider-neutral transcript of a video. The WebVTT stored
+// in "Video".transcription and every export format are renderings of it.
+type Transcript struct {
+	Language string    `json:"language,omitempty"`
+	Segments []Segment `json:"segments"`
+}
+
+// Segment is a stretch of speech as the provider split it, usually a sentence
+// or a subtitle cue
+type Segment struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+	// Speaker is the provider's diarization label, such as "SPEAKER_00",
+	// when speakers were detected. Recordings transcribed in segments are
+	// labelled per segment, so a label may not be one person throughout.
+	Speaker string
+	// Words carries word timings when the provider returned them
+	Words []Word
+	// Confidence is between 0 and 1 when the provider reported one, such as
+	// Whisper's avg_logprob
+	Confidence *float64
+}
+
+// Word is a single timed word within a segment
+type Word struct {
+	Start      time.Duration
+	End        time.Duration
+	Text       string
+	Confidence *float64
+}
+
+// segmentJSON and wordJSON store times as seconds, like the provider formats
+type segmentJSON struct {
+	Start      float64  `json:"start"`
+	End        float64  `json:"end"`
+	Text       string   `json:"text"`
+	Speaker    string   `json:"speaker,omitempty"`
+	Words      []Word   `json:"words,omitempty"`
+	Confidence *float64 `json:"confidence,omitempty"`
+}
+
+type wordJSON struct {
+	Start      float64  `json:"start"`
+	End        float64  `json:"end"`
+	Text       string   `json:"text"`
+	Confidence *float64 `json:"confidence,omitempty"`
+}
+
+func (s Segment) MarshalJSON() ([]byte, error) {
+	return json.Marshal(segmentJSON{
+		Start:      s.Start.Seconds(),
+		End:        s.End.Seconds(),
+		Text:       s.Text,
+		Speaker:    s.Speaker,
+		Words:      s.Words,
+		Confidence: s.Confidence,
+	})
+}
+
+func (s *Segment) UnmarshalJSON(data []byte) error {
+	var v segmentJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = Segment{
+		Start:      SecondsToDuration(v.Start),
+		End:        SecondsToDuration(v.End),
+		Text:       v.Text,
+		Speaker:    v.Speaker,
+		Words:      v.Words,
+		Confidence: v.Confidence,
+	}
+	return nil
+}
+
+func (w Word) MarshalJSON() ([]byte, error) {
+	return json.Marshal(wordJSON{
+		Start:      w.Start.Seconds(),
+		End:        w.End.Seconds(),
+		Text:       w.Text,
+		Confidence: w.Confidence,
+	})
+}
+
+func (w *Word) UnmarshalJSON(data []byte) error {
+	var v wordJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*w = Word{
+		Start:      SecondsToDuration(v.Start),
+		End:        SecondsToDuration(v.End),
+		Text:       v.Text,
+		Confidence: v.Confidence,
+	}
+	return nil
+}
+
+// SecondsToDuration converts seconds, as providers and ffmpeg report them,
+// to a duration rounded to the millisecond
+func SecondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Millisecond)
+}
+
+// TranscriptFromEntries builds a transcript without words or confidence from
+// parsed subtitle cues
+func TranscriptFromEntries(entries []SRTEntry) *Transcript {
+	transcript := &Transcript{Segments: make([]Segment, 0, len(entries))}
+	for _, entry := range entries {
+		transcript.Segments = append(transcript.Segments, Segment{
+			Start:   entry.Start,
+			End:     entry.End,
+			Text:    entry.Text,
+			Speaker: entry.Speaker,
+		})
+	}
+	return transcript
+}
+
+// Entries flattens the transcript into numbered cues for chunking and the
+// subtitle formats
+func (t *Transcript) Entries() []SRTEntry {
+	entries := make([]SRTEntry, 0, len(t.Segments))
+	for i, segment := range t.Segments {
+		entries = append(entries, SRTEntry{
+			Number:  i + 1,
+			Start:   segment.Start,
+			End:     segment.End,
+			Text:    segment.Text,
+			Speaker: segment.Speaker,
+		})
+	}
+	return entries
+}
+
+// Text joins the text of every segment
+func (t *Transcript) Text() string {
+	texts := make([]string, 0, len(t.Segments))
+	for _, segment := range t.Segments {
+		texts = append(texts, segment.Text)
+	}
+	return strings.Join(texts, " ")
+}
+
+// Speakers returns the distinct speaker labels in the order they first speak
+func (t *Transcript) Speakers() []string {
+	seen := map[string]bool{}
+	var speakers []string
+	for _, segment := range t.Segments {
+		if segment.Speaker != "" && !seen[segment.Speaker] {
+			seen[segment.Speaker] = true
+			speakers = append(speakers, segment.Speaker)
+		}
+	}
+	return speakers
+}
+
+// WithSpeakerNames returns a copy of the transcript with speaker labels
+// replaced by the names given for them. Labels without a name are kept.
+func (t *Transcript) WithSpeakerNames(names map[string]string) *Transcript {
+	if len(names) == 0 {
+		return t
+	}
+	named := &Transcript{Language: t.Language, Segments: make([]Segment, len(t.Segments))}
+	for i, segment := range t.Segments {
+		segment.Speaker = SpeakerName(names, segment.Speaker)
+		named.Segments[i] = segment
+	}
+	return named
+}
+
+// SpeakerName is the name given for a speaker label, or the label itself
+func SpeakerName(names map[string]string, label string) string {
+	if name, ok := names[label]; ok && name != "" {
+		return name
+	}
+	return label
+}
+
+// FindWord returns the first word of the longest run of words that appear in
+// the query, the earliest run winning ties. Query terms shorter than three
+// letters are ignored unless the query has nothing longer, so "a" or "is"
+// alone never decides the match.
+func FindWord(words []Word, query string) (Word, bool) {
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return Word{}, false
+	}
+
+	best, bestLen := -1, 0
+	run := 0
+	for i, word := range words {
+		if !terms[NormalizeWord(word.Text)] {
+			run = 0
+			continue
+		}
+		run++
+		if run > bestLen {
+			best, bestLen = i-run+1, run
+		}
+	}
+	if best < 0 {
+		return Word{}, false
+	}
+	return words[best], true
+}
+
+func queryTerms(query string) map[string]bool {
+	all := map[string]bool{}
+	long := map[string]bool{}
+	for _, field := range strings.Fields(query) {
+		term := NormalizeWord(field)
+		if term == "" {
+			continue
+		}
+		all[term] = true
+		if len([]rune(term)) >= 3 {
+			long[term] = true
+		}
+	}
+	if len(long) > 0 {
+		return long
+	}
+	return all
+}
+
+// NormalizeWord lowercases a word and strips the punctuation around it, so
+// words compare the same however they were written
+func NormalizeWord(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}))
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTranscriptJSONRoundTrip(t *testing.T) {
+	confidence := 0.9
+	transcript := Transcript{
+		Language: "en",
+		Segments: []Segment{{
+			Start:      1500 * time.Millisecond,
+			End:        3 * time.Second,
+			Text:       "Hello there",
+			Confidence: &confidence,
+			Words: []Word{
+				{Start: 1500 * time.Millisecond, End: 2 * time.Second, Text: "Hello"},
+				{Start: 2 * time.Second, End: 3 * time.Second, Text: "there", Confidence: &confidence},
+			},
+		}},
+	}
+
+	data, err := json.Marshal(transcript)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `{"language":"en","segments":[{"start":1.5,"end":3,"text":"Hello there",` +
+		`"words":[{"start":1.5,"end":2,"text":"Hello"},{"start":2,"end":3,"text":"there","confidence":0.9}],"confidence":0.9}]}`
+	if string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var decoded Transcript
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(decoded, transcript) {
+		t.Errorf("round trip = %+v, want %+v", decoded, transcript)
+	}
+}
+
+func TestTranscriptEntries(t *testing.T) {
+	entries := []SRTEntry{
+		{Number: 1, Start: 0, End: time.Second, Text: "one"},
+		{Number: 2, Start: time.Second, End: 2 * time.Second, Text: "two"},
+	}
+	transcript := TranscriptFromEntries(entries)
+	if got := transcript.Entries(); !reflect.DeepEqual(got, entries) {
+		t.Errorf("Entries() = %+v, want %+v", got, entries)
+	}
+	if got := transcript.Text(); got != "one two" {
+		t.Errorf("Text() = %q", got)
+	}
+}
+
+func TestFindWord(t *testing.T) {
+	words := []Word{
+		{Start: 10 * time.Second, Text: "The"},
+		{Start: 11 * time.Second, Text: "garbage"},
+		{Start: 12 * time.Second, Text: "collector"},
+		{Start: 13 * time.Second, Text: "is"},
+		{Start: 14 * time.Second, Text: "concurrent."},
+		{Start: 15 * time.Second, Text: "Garbage,"},
+		{Start: 16 * time.Second, Text: "Collector"},
+		{Start: 17 * time.Second, Text: "tuning"},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  time.Duration
+		found bool
+	}{
+		{name: "longest run wins", query: "garbage collector tuning", want: 15 * time.Second, found: true},
+		{name: "earliest of equal runs", query: "Garbage collector", want: 11 * time.Second, found: true},
+		{name: "punctuation ignored", query: "concurrent?", want: 14 * time.Second, found: true},
+		{name: "short words ignored", query: "a tuning", want: 17 * time.Second, found: true},
+		{name: "only short words", query: "is", want: 13 * time.Second, found: true},
+		{name: "no match", query: "scheduler", found: false},
+		{name: "empty query", query: " ", found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := FindWord(words, tt.query)
+			if found != tt.found || (found && got.Start != tt.want) {
+				t.Errorf("FindWord(%q) = %v, %v, want %v, %v", tt.query, got.Start, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestLocateMatchFallsBackToChunkStart(t *testing.T) {
+	result := SearchResult{StartTime: 30}
+	result.LocateMatch("anything")
+	if result.MatchTime != 30 {
+		t.Errorf("MatchTime = %v, want the chunk start", result.MatchTime)
+	}
+
+	result.Words = []Word{{Start: 31500 * time.Millisecond, Text: "anything"}}
+	result.LocateMatch("anything")
+	if result.MatchTime != 31.5 {
+		t.Errorf("MatchTime = %v, want the word start", result.MatchTime)
+	}
+}
+
+func TestWithSpeakerNames(t *testing.T) {
+	transcript := &Transcript{Segments: []Segment{
+		{Text: "one", Speaker: "SPEAKER_00"},
+		{Text: "two", Speaker: "SPEAKER_01"},
+		{Text: "three", Speaker: "SPEAKER_00"},
+	}}
+
+	named := transcript.WithSpeakerNames(map[string]string{"SPEAKER_01": "Ada"})
+	if named.Segments[0].Speaker != "SPEAKER_00" || named.Segments[1].Speaker != "Ada" {
+		t.Errorf("WithSpeakerNames() = %+v, want only SPEAKER_01 renamed", named.Segments)
+	}
+	if transcript.Segments[1].Speaker != "SPEAKER_01" {
+		t.Error("WithSpeakerNames() changed the original transcript")
+	}
+	if got := transcript.Speakers(); !reflect.DeepEqual(got, []string{"SPEAKER_00", "SPEAKER_01"}) {
+		t.Errorf("Speakers() = %v", got)
+	}
+}
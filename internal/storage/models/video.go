@@ -5,39 +5,230 @@ import (
 	"time"
 )
 
+// Video source types
+const (
+	SourceYouTube = "youtube"
+	SourceRSS     = "rss"
+	SourceUpload  = "upload"
+)
+
+// UploadURLPrefix marks the "videoUrl" of an uploaded recording; the rest is
+// the file name inside the upload directory
+const UploadURLPrefix = "upload://"
+
 type Video struct {
-	ID            string     `json:"id"`
-	VideoURL      string     `json:"videoUrl"`
-	Title         string     `json:"title"`
-	Slug          string     `json:"slug"`
-	Transcription *string    `json:"transcription,omitempty"`
-	Status        string     `json:"status"`
-	CreatedAt     time.Time  `json:"createdAt"`
-	UpdatedAt     time.Time  `json:"updatedAt"`
-	UserID        string     `json:"userId"`
-	IsSearchable  bool       `json:"isSearchable"`
+	ID                string      `json:"id"`
+	VideoURL          string      `json:"videoUrl"`
+	Title             string      `json:"title"`
+	Slug              string      `json:"slug"`
+	SourceType        string      `json:"sourceType"`
+	Transcription     *string     `json:"transcription,omitempty"`
+	Transcript        *Transcript `json:"-"`
+	Translation       *Transcript `json:"-"`
+	TranscriptSource  *string     `json:"transcriptSource,omitempty"`
+	Status            string      `json:"status"`
+	CreatedAt         time.Time   `json:"createdAt"`
+	UpdatedAt         time.Time   `json:"updatedAt"`
+	UserID            string      `json:"userId"`
+	IsSearchable      bool        `json:"isSearchable"`
+	Attempts          int         `json:"attempts"`
+	LastError         *string     `json:"lastError,omitempty"`
+	DurationSeconds   *float64    `json:"durationSeconds,omitempty"`
+	ChannelName       *string     `json:"channelName,omitempty"`
+	ChannelID         *string     `json:"channelId,omitempty"`
+	UploadDate        *time.Time  `json:"uploadDate,omitempty"`
+	Description       *string     `json:"description,omitempty"`
+	ThumbnailURL      *string     `json:"thumbnailUrl,omitempty"`
+	Tags              []string    `json:"tags,omitempty"`
+	Chapters          []Chapter   `json:"chapters,omitempty"`
+	Language          *string     `json:"language,omitempty"`
+	RequestedLanguage *string     `json:"requestedLanguage,omitempty"`
+	DetectedLanguage  *string     `json:"detectedLanguage,omitempty"`
+	Translate         bool        `json:"translate"`
+	// SpeakerNames maps diarization labels such as "SPEAKER_00" to the names
+	// shown in exports and search results
+	SpeakerNames map[string]string `json:"speakerNames,omitempty"`
+}
+
+// VideoSpeaker is a diarization label and the name given to it, if any
+type VideoSpeaker struct {
+	Label string `json:"label"`
+	Name  string `json:"name,omitempty"`
+}
+
+type SpeakersResponse struct {
+	Speakers []VideoSpeaker `json:"speakers"`
+}
+
+// VideoMetadata is the descriptive information yt-dlp reports for a video
+type VideoMetadata struct {
+	Title           string
+	DurationSeconds float64
+	ChannelName     string
+	ChannelID       string
+	UploadDate      *time.Time
+	Description     string
+	ThumbnailURL    string
+	Tags            []string
+	Chapters        []Chapter
+	Language        string
+}
+
+type Chapter struct {
+	Title     string  `json:"title"`
+	StartTime float64 `json:"startTime"` // seconds from the start of the video
+	EndTime   float64 `json:"endTime"`
 }
 
 type VideoRequest struct {
 	URL          string `json:"url"`
 	IsSearchable bool   `json:"isSearchable"`
+	// Language is the ISO-639-1 code of the spoken language; when empty it is
+	// detected
+	Language string `json:"language,omitempty"`
+	// Translate also stores an English translation of the transcript
+	Translate bool `json:"translate,omitempty"`
 }
 
+// Search modes: semantic ranks chunks by vector similarity, keyword by
+// full-text rank, and hybrid fuses both rankings
+const (
+	SearchModeSemantic = "semantic"
+	SearchModeKeyword  = "keyword"
+	SearchModeHybrid   = "hybrid"
+)
+
 type SearchRequest struct {
 	Query string `json:"query"`
 	Limit int    `json:"limit"`
+	// Mode is one of the SearchMode constants, hybrid when empty
+	Mode string `json:"mode,omitempty"`
+	SearchFilter
+	// Cursor continues from the NextCursor of a previous response to the
+	// same request
+	Cursor string `json:"cursor,omitempty"`
+	// GroupByVideo returns the best PerVideo chunks of each video, and Limit
+	// counts videos rather than chunks
+	GroupByVideo bool `json:"groupByVideo,omitempty"`
+	PerVideo     int  `json:"perVideo,omitempty"`
+}
+
+// SearchFilter narrows a search; fields left empty don't filter
+type SearchFilter struct {
+	VideoIDs  []string `json:"videoIds,omitempty"`
+	UserID    string   `json:"userId,omitempty"`
+	ChannelID string   `json:"channelId,omitempty"`
+	// UploadedFrom and UploadedTo bound the upload date, inclusive, as
+	// YYYY-MM-DD
+	UploadedFrom string `json:"uploadedFrom,omitempty"`
+	UploadedTo   string `json:"uploadedTo,omitempty"`
+	// CreatedAfter (inclusive) and CreatedBefore (exclusive) bound when the
+	// video was added
+	CreatedAfter  *time.Time `json:"createdAfter,omitempty"`
+	CreatedBefore *time.Time `json:"createdBefore,omitempty"`
+	// Language matches the detected, requested or metadata language of the
+	// video, ignoring case
+	Language string `json:"language,omitempty"`
+	// MinSimilarity drops chunks less similar to the query. Keyword search
+	// doesn't compare vectors, so it can't apply it.
+	MinSimilarity float64 `json:"minSimilarity,omitempty"`
 }
 
 type SearchResponse struct {
+	// Results are the chunks found, or empty when grouped by video
+	Results []SearchResult `json:"results"`
+	// Videos holds the chunks grouped by video, in the order of each video's
+	// best chunk, when the request asks for it
+	Videos []VideoSearchResults `json:"videos,omitempty"`
+	// NextCursor fetches the next page; it is empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
+	Mode       string `json:"mode"`
+	// Model is the embedding model the results were ranked with
+	Model string `json:"model"`
+	// Truncated means ranking stopped at its depth limit before it could
+	// fill this page, so more matches may exist that can't be paged to
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// VideoSearchResults are the best matching chunks of one video
+type VideoSearchResults struct {
+	VideoID string `json:"videoId"`
+	Title   string `json:"title"`
+	// Score is the score of the video's best chunk
+	Score   float64        `json:"score"`
 	Results []SearchResult `json:"results"`
 }
 
 type SearchResult struct {
-	VideoID       string  `json:"videoId"`
-	ChunkText     string  `json:"chunkText"`
-	StartPosition int     `json:"startPosition"`
-	EndPosition   int     `json:"endPosition"`
-	Similarity    float64 `json:"similarity"`
+	ChunkID    int64   `json:"chunkId"`
+	VideoID    string  `json:"videoId"`
+	Title      string  `json:"title"`
+	ChunkText  string  `json:"chunkText"`
+	StartTime  float64 `json:"startTime"` // seconds from the start of the video
+	EndTime    float64 `json:"endTime"`
+	Similarity float64 `json:"similarity"`
+	// KeywordRank is the full-text rank of the chunk, between 0 and 1, when
+	// the query's words appear in it
+	KeywordRank float64 `json:"keywordRank,omitempty"`
+	// Score orders the results: the similarity in semantic mode, the keyword
+	// rank in keyword mode and the fused reciprocal rank in hybrid mode
+	Score float64 `json:"score"`
+	// MatchTime is where the words of the query are spoken, or StartTime
+	// when the chunk has no word timings or no word matches
+	MatchTime float64 `json:"matchTime"`
+	// Speakers are the names, or labels when unnamed, of who speaks in the
+	// chunk, in order
+	Speakers []string `json:"speakers,omitempty"`
+	Words    []Word   `json:"-"`
+}
+
+// LocateMatch points MatchTime at the words of the query within the chunk,
+// falling back to the start of the chunk
+func (r *SearchResult) LocateMatch(query string) {
+	r.MatchTime = r.StartTime
+	if word, ok := FindWord(r.Words, query); ok {
+		r.MatchTime = word.Start.Seconds()
+	}
+}
+
+type AskRequest struct {
+	Question string `json:"question"`
+	// Limit is how many transcript chunks are retrieved as sources
+	Limit int `json:"limit"`
+	// Mode is the search mode the sources are retrieved with
+	Mode string `json:"mode,omitempty"`
+	SearchFilter
+	// Stream sends the answer as server-sent events while it is written
+	Stream bool `json:"stream,omitempty"`
+}
+
+type AskResponse struct {
+	Answer string `json:"answer"`
+	// Citations are the sources the answer refers to as [n], in the order
+	// they are first cited
+	Citations []Citation `json:"citations"`
+	// Model is the chat model that wrote the answer, empty when nothing
+	// matched the question and no model was asked
+	Model string `json:"model,omitempty"`
+}
+
+// Citation is a transcript chunk given to the chat model as source [Number]
+type Citation struct {
+	Number    int     `json:"number"`
+	ChunkID   int64   `json:"chunkId"`
+	VideoID   string  `json:"videoId"`
+	Title     string  `json:"title"`
+	StartTime float64 `json:"startTime"` // seconds from the start of the video
+	EndTime   float64 `json:"endTime"`
+	Text      string  `json:"text"`
+}
+
+// EmbeddingModelStats counts the search chunks embedded with one model
+type EmbeddingModelStats struct {
+	Model      string `json:"model"`
+	Dimensions int    `json:"dimensions"`
+	Chunks     int    `json:"chunks"`
+	Videos     int    `json:"videos"`
 }
 
 type SRTEntry struct {
@@ -45,6 +236,7 @@ type SRTEntry struct {
 	Start     time.Duration
 	End       time.Duration
 	Text      string
+	Speaker   string
 }
 
 type Chunk struct {
@@ -52,13 +244,18 @@ type Chunk struct {
 	StartTime     time.Duration
 	EndTime       time.Duration
 	Embedding     []float32
+	Words         []Word
+	Speakers      []string
 }
 
+// youtubePathPrefixes are the URL forms that carry the video ID in the path
+var youtubePathPrefixes = []string{"youtu.be/", "/shorts/", "/embed/", "/live/"}
+
 func ExtractSlugFromURL(url string) string {
 	// Find the v= parameter
 	vIndex := strings.Index(url, "v=")
 	if vIndex == -1 {
-		return ""
+		return extractSlugFromPath(url)
 	}
 	
 	// Start after "v="
@@ -71,4 +268,21 @@ func ExtractSlugFromURL(url string) string {
 	}
 	
 	return slug
-}
\ No newline at end of file
+}
+
+// extractSlugFromPath handles youtu.be, shorts, embed and live URLs
+func extractSlugFromPath(url string) string {
+	for _, prefix := range youtubePathPrefixes {
+		index := strings.Index(url, prefix)
+		if index == -1 {
+			continue
+		}
+
+		slug := url[index+len(prefix):]
+		if end := strings.IndexAny(slug, "?&#/"); end != -1 {
+			slug = slug[:end]
+		}
+		return slug
+	}
+	return ""
+}
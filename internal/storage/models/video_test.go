@@ -0,0 +1,24 @@
+package models
+
+import "testing"
+
+func TestExtractSlugFromURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://www.youtube.com/watch?v=wAzBl6xllzE", "wAzBl6xllzE"},
+		{"https://www.youtube.com/watch?v=wAzBl6xllzE&list=PL123&index=2", "wAzBl6xllzE"},
+		{"https://youtu.be/wAzBl6xllzE?si=abc", "wAzBl6xllzE"},
+		{"https://www.youtube.com/shorts/wAzBl6xllzE", "wAzBl6xllzE"},
+		{"https://www.youtube.com/embed/wAzBl6xllzE", "wAzBl6xllzE"},
+		{"https://www.youtube.com/live/wAzBl6xllzE?feature=share", "wAzBl6xllzE"},
+		{"https://example.com/video.mp4", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ExtractSlugFromURL(tt.url); got != tt.want {
+			t.Errorf("ExtractSlugFromURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
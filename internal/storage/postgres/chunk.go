@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+// ChunkRepository manages search chunks across embedding models, so a video
+// can be re-embedded with a new model next to its current vectors
+type ChunkRepository struct {
+	db *sql.DB
+}
+
+func NewChunkRepository(db *sql.DB) *ChunkRepository {
+	return &ChunkRepository{db: db}
+}
+
+// EmbeddingModels counts the chunks and videos embedded with each model
+func (r *ChunkRepository) EmbeddingModels(ctx context.Context) ([]models.EmbeddingModelStats, error) {
+	const query = `
+		SELECT COALESCE(embedding_model, ''), COALESCE(embedding_dimensions, 0), COUNT(*), COUNT(DISTINCT video_id)
+		FROM "VideoChunk"
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("embedding model query failed: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []models.EmbeddingModelStats{}
+	for rows.Next() {
+		var s models.EmbeddingModelStats
+		if err := rows.Scan(&s.Model, &s.Dimensions, &s.Chunks, &s.Videos); err != nil {
+			return nil, fmt.Errorf("failed to scan embedding model: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// VideosToReindex lists the chunked videos that have no vectors from model,
+// or every chunked video when all is set. A non-empty videoIDs limits the list
+// to those videos.
+func (r *ChunkRepository) VideosToReindex(ctx context.Context, model string, videoIDs []string, all bool) ([]string, error) {
+	const query = `
+		SELECT DISTINCT c.video_id
+		FROM "VideoChunk" c
+		WHERE (cardinality($2::text[]) = 0 OR c.video_id = ANY($2::text[]))
+		AND ($3 OR NOT EXISTS (
+			SELECT 1 FROM "VideoChunk" m WHERE m.video_id = c.video_id AND m.embedding_model = $1
+		))
+		ORDER BY c.video_id
+	`
+	rows, err := r.db.QueryContext(ctx, query, model, pq.Array(videoIDs), all)
+	if err != nil {
+		return nil, fmt.Errorf("reindex query failed: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan video id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SourceChunks returns the video's chunks without their vectors, ready to be
+// embedded again. When the video has chunks from several models, the most
+// recently written set is used.
+func (r *ChunkRepository) SourceChunks(ctx context.Context, videoID string) ([]models.Chunk, error) {
+	const query = `
+		SELECT chunk_text, EXTRACT(EPOCH FROM chunk_start_time), EXTRACT(EPOCH FROM chunk_end_time), words, speakers
+		FROM "VideoChunk"
+		WHERE video_id = $1
+		AND embedding_model IS NOT DISTINCT FROM (
+			SELECT embedding_model FROM "VideoChunk" WHERE video_id = $1 ORDER BY created_at DESC, id DESC LIMIT 1
+		)
+		ORDER BY chunk_start_time, id
+	`
+	rows, err := r.db.QueryContext(ctx, query, videoID)
+	if err != nil {
+		return nil, fmt.Errorf("chunk query failed: %w", err)
+	}
+	defer rows.Close()
+
+	chunks := []models.Chunk{}
+	for rows.Next() {
+		var chunk models.Chunk
+		var start, end float64
+		var words []byte
+		if err := rows.Scan(&chunk.Text, &start, &end, &words, pq.Array(&chunk.Speakers)); err != nil {
+			return nil, fmt.Errorf("failed to scan chunk: %w", err)
+		}
+		chunk.StartTime = time.Duration(start * float64(time.Second)).Round(time.Millisecond)
+		chunk.EndTime = time.Duration(end * float64(time.Second)).Round(time.Millisecond)
+		if words != nil {
+			if err := json.Unmarshal(words, &chunk.Words); err != nil {
+				return nil, fmt.Errorf("failed to decode chunk words: %w", err)
+			}
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks, rows.Err()
+}
+
+// ReplaceModelChunks replaces the video's chunks from model only, leaving
+// vectors from other models in place
+func (r *ChunkRepository) ReplaceModelChunks(ctx context.Context, videoID string, model string, chunks []models.Chunk) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM "VideoChunk" WHERE video_id = $1 AND embedding_model = $2`, videoID, model); err != nil {
+		return fmt.Errorf("delete existing chunks failed: %w", err)
+	}
+	if err := insertChunks(tx, videoID, model, chunks); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PruneOtherModels deletes vectors from models other than model, but only for
+// videos that already have vectors from model, so no video drops out of
+// search. It returns the number of chunks deleted.
+func (r *ChunkRepository) PruneOtherModels(ctx context.Context, model string) (int64, error) {
+	const query = `
+		DELETE FROM "VideoChunk" c
+		WHERE c.embedding_model IS DISTINCT FROM $1
+		AND EXISTS (SELECT 1 FROM "VideoChunk" m WHERE m.video_id = c.video_id AND m.embedding_model = $1)
+	`
+	result, err := r.db.ExecContext(ctx, query, model)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune chunks: %w", err)
+	}
+	return result.RowsAffected()
+}
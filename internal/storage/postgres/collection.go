@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+// lockVideoInserts serializes the transactions that insert a video only when
+// its slug isn't already stored. "Video".slug can't be unique since a URL may
+// be transcribed more than once, so without the lock two of them could both
+// find a video missing and insert it twice.
+const lockVideoInserts = `SELECT pg_advisory_xact_lock(hashtext('"Video".slug'))`
+
+type CollectionRepository struct {
+	db *sql.DB
+}
+
+func NewCollectionRepository(db *sql.DB) *CollectionRepository {
+	return &CollectionRepository{db: db}
+}
+
+// CreateWithVideos records the playlist as a collection and inserts a "Video"
+// row for each entry whose ID isn't already present as a slug. Every entry,
+// new or existing, is linked to the collection. Importing the same playlist
+// again only adds the videos published since. It holds lockVideoInserts
+// while inserting.
+func (r *CollectionRepository) CreateWithVideos(ctx context.Context, playlist *models.Playlist, isSearchable bool) (*models.CollectionResponse, error) {
+	const upsertCollection = `
+		INSERT INTO "Collection" (id, "sourceUrl", title, kind, "userId", "createdAt", "updatedAt")
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT ("sourceUrl") DO UPDATE
+		SET title = EXCLUDED.title, "updatedAt" = CURRENT_TIMESTAMP
+		RETURNING id
+	`
+	const insertVideo = `
+		INSERT INTO "Video" (id, "videoUrl", slug, status, "isSearchable", "createdAt", "updatedAt", "userId", title, "sourceType")
+		SELECT gen_random_uuid(), $1, $2, 'pending', $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $4, NULLIF($5, ''), $6
+		WHERE NOT EXISTS (SELECT 1 FROM "Video" WHERE slug = $2)
+		RETURNING id
+	`
+	const findVideo = `
+		SELECT id FROM "Video" WHERE slug = $1 ORDER BY "createdAt" LIMIT 1
+	`
+	const linkVideo = `
+		INSERT INTO "CollectionVideo" (collection_id, video_id, position)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (collection_id, video_id) DO UPDATE SET position = EXCLUDED.position
+	`
+
+	userID := os.Getenv("VIDEO_OWNER_USER_ID")
+	if userID == "" {
+		return nil, fmt.Errorf("VIDEO_OWNER_USER_ID environment variable must be set")
+	}
+
+	sourceType := models.SourceYouTube
+	if playlist.Kind == models.CollectionFeed {
+		sourceType = models.SourceRSS
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, lockVideoInserts); err != nil {
+		return nil, fmt.Errorf("import lock failed: %w", err)
+	}
+
+	response := &models.CollectionResponse{
+		Title:    playlist.Title,
+		Kind:     playlist.Kind,
+		VideoIDs: []string{},
+	}
+	err = tx.QueryRowContext(ctx, upsertCollection, playlist.URL, playlist.Title, playlist.Kind, userID).Scan(&response.ID)
+	if err != nil {
+		return nil, fmt.Errorf("collection insert failed: %w", err)
+	}
+
+	for i, entry := range playlist.Entries {
+		var videoID string
+		err := tx.QueryRowContext(ctx, insertVideo, entry.URL, entry.ID, isSearchable, userID, entry.Title, sourceType).Scan(&videoID)
+		switch {
+		case err == sql.ErrNoRows:
+			if err := tx.QueryRowContext(ctx, findVideo, entry.ID).Scan(&videoID); err != nil {
+				return nil, fmt.Errorf("existing video lookup failed: %w", err)
+			}
+			response.Skipped++
+		case err != nil:
+			return nil, fmt.Errorf("video insert failed: %w", err)
+		default:
+			response.Added++
+			response.VideoIDs = append(response.VideoIDs, videoID)
+		}
+
+		if _, err := tx.ExecContext(ctx, linkVideo, response.ID, videoID, i); err != nil {
+			return nil, fmt.Errorf("collection link failed: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return response, nil
+}
@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+// JobRepository is a Postgres-backed work queue for transcription jobs.
+// Workers claim jobs with SELECT ... FOR UPDATE SKIP LOCKED so several
+// workers can share the table without processing the same video twice.
+type JobRepository struct {
+	db *sql.DB
+}
+
+func NewJobRepository(db *sql.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// EnqueueUnfinished makes sure every pending or processing video has a queued
+// job. This picks up videos inserted while no worker was listening and videos
+// whose finished job was reset by hand, which start over with a fresh retry
+// budget.
+func (r *JobRepository) EnqueueUnfinished(ctx context.Context) (int64, error) {
+	const query = `
+		INSERT INTO "TranscriptionJob" (video_id)
+		SELECT id FROM "Video" WHERE status IN ('pending', 'processing')
+		ON CONFLICT (video_id) DO UPDATE
+		SET status = 'queued', attempts = 0, last_error = NULL,
+			run_after = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE "TranscriptionJob".status IN ('done', 'failed')
+	`
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue unfinished videos: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// RequeueStale returns running jobs whose worker stopped sending heartbeats
+// within the lease back to the queue
+func (r *JobRepository) RequeueStale(ctx context.Context, lease time.Duration) (int64, error) {
+	const query = `
+		UPDATE "TranscriptionJob"
+		SET status = 'queued', locked_by = NULL, locked_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE status = 'running'
+		AND locked_at < CURRENT_TIMESTAMP - make_interval(secs => $1)
+	`
+	result, err := r.db.ExecContext(ctx, query, lease.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue stale jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Claim locks the oldest runnable job for the worker. It returns nil when the
+// queue is empty.
+func (r *JobRepository) Claim(ctx context.Context, workerID string) (*models.Job, error) {
+	const query = `
+		UPDATE "TranscriptionJob"
+		SET status = 'running', attempts = attempts + 1, locked_by = $1,
+			locked_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM "TranscriptionJob"
+			WHERE status = 'queued' AND run_after <= CURRENT_TIMESTAMP
+			ORDER BY run_after, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING id, video_id, status, attempts, last_error, locked_by, locked_at, run_after
+	`
+
+	var job models.Job
+	err := r.db.QueryRowContext(ctx, query, workerID).Scan(
+		&job.ID,
+		&job.VideoID,
+		&job.Status,
+		&job.Attempts,
+		&job.LastError,
+		&job.LockedBy,
+		&job.LockedAt,
+		&job.RunAfter,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return &job, nil
+}
+
+// Heartbeat extends the worker's lease on a running job
+func (r *JobRepository) Heartbeat(ctx context.Context, jobID int64, workerID string) error {
+	const query = `
+		UPDATE "TranscriptionJob"
+		SET locked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND locked_by = $2 AND status = 'running'
+	`
+	if _, err := r.db.ExecContext(ctx, query, jobID, workerID); err != nil {
+		return fmt.Errorf("failed to extend job lease: %w", err)
+	}
+	return nil
+}
+
+func (r *JobRepository) Complete(ctx context.Context, jobID int64) error {
+	return r.finish(ctx, jobID, models.JobDone, nil)
+}
+
+func (r *JobRepository) Fail(ctx context.Context, jobID int64, errMsg string) error {
+	return r.finish(ctx, jobID, models.JobFailed, &errMsg)
+}
+
+// Retry puts the job back in the queue to run again after the delay
+func (r *JobRepository) Retry(ctx context.Context, jobID int64, delay time.Duration, errMsg string) error {
+	const query = `
+		UPDATE "TranscriptionJob"
+		SET status = 'queued', last_error = $1, locked_by = NULL, locked_at = NULL,
+			run_after = CURRENT_TIMESTAMP + make_interval(secs => $2), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+	`
+	if _, err := r.db.ExecContext(ctx, query, errMsg, delay.Seconds(), jobID); err != nil {
+		return fmt.Errorf("failed to reschedule job: %w", err)
+	}
+	return nil
+}
+
+func (r *JobRepository) finish(ctx context.Context, jobID int64, status string, errMsg *string) error {
+	const query = `
+		UPDATE "TranscriptionJob"
+		SET status = $1, last_error = $2, locked_by = NULL, locked_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+	`
+	result, err := r.db.ExecContext(ctx, query, status, errMsg, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("no job found with ID: %d", jobID)
+	}
+
+	return nil
+}
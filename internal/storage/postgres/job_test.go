@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// testDB connects to TEST_DATABASE_URL, a database set up with setup.sql.
+// Tests that need one are skipped without it.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestEnqueueUnfinishedResetsAttempts(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	// Inserting the video queues its job
+	videoID := fmt.Sprintf("test-requeue-%d", time.Now().UnixNano())
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO "Video" (id, "videoUrl", slug, status, "userId")
+		VALUES ($1, 'https://www.youtube.com/watch?v=test', 'test', 'failed', 'test-user')
+	`, videoID)
+	if err != nil {
+		t.Fatalf("video insert failed: %v", err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM "Video" WHERE id = $1`, videoID) })
+
+	// The job gave up after its retries, and on-call resets the video by hand
+	_, err = db.ExecContext(ctx, `
+		UPDATE "TranscriptionJob" SET status = 'failed', attempts = 5, last_error = 'rate limited'
+		WHERE video_id = $1
+	`, videoID)
+	if err != nil {
+		t.Fatalf("job update failed: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE "Video" SET status = 'pending' WHERE id = $1`, videoID); err != nil {
+		t.Fatalf("video update failed: %v", err)
+	}
+
+	if _, err := NewJobRepository(db).EnqueueUnfinished(ctx); err != nil {
+		t.Fatalf("EnqueueUnfinished() error = %v", err)
+	}
+
+	var status string
+	var attempts int
+	var lastError sql.NullString
+	err = db.QueryRowContext(ctx, `SELECT status, attempts, last_error FROM "TranscriptionJob" WHERE video_id = $1`, videoID).
+		Scan(&status, &attempts, &lastError)
+	if err != nil {
+		t.Fatalf("job query failed: %v", err)
+	}
+	if status != "queued" || attempts != 0 || lastError.Valid {
+		t.Errorf("job = %s after %d attempts, last error %v; want queued with a fresh retry budget", status, attempts, lastError)
+	}
+}
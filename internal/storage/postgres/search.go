@@ -0,0 +1,254 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+type SearchRepository struct {
+	db *sql.DB
+}
+
+func NewSearchRepository(db *sql.DB) *SearchRepository {
+	return &SearchRepository{db: db}
+}
+
+// ModelMismatchError is returned when no chunks carry vectors from the query's
+// embedding model but chunks from other models exist, so the index needs
+// re-embedding before it can be searched with that model
+type ModelMismatchError struct {
+	Model     string
+	Available []string
+}
+
+func (e *ModelMismatchError) Error() string {
+	return fmt.Sprintf("no chunks are embedded with %s (found %s); reindex with this model first",
+		e.Model, strings.Join(e.Available, ", "))
+}
+
+// searchColumns are the chunk and video columns every search selects after
+// the similarity and keyword rank, in the order scanSearchResults reads them
+const searchColumns = `c.id, c.video_id, COALESCE(v.title, ''), c.chunk_text,
+		EXTRACT(EPOCH FROM c.chunk_start_time), EXTRACT(EPOCH FROM c.chunk_end_time),
+		c.words, c.speakers, v."speakerNames"`
+
+// keywordRankNormalization divides ts_rank_cd by 1 + the log of the chunk's
+// length (1) and maps it into 0..1 as rank / (rank + 1) (32), so long chunks
+// don't win just by repeating words
+const keywordRankNormalization = 1 | 32
+
+// SemanticSearch returns the chunks closest to the query embedding by cosine
+// distance, comparing only vectors from the same model. Ordering by the <=>
+// operator with a LIMIT lets Postgres use the partial ivfflat index for
+// vectors of that size.
+func (r *SearchRepository) SemanticSearch(ctx context.Context, embedding []float32, model string, filter models.SearchFilter, limit int) ([]models.SearchResult, error) {
+	var args queryArgs
+	// The column holds vectors of any size, so the dimensions are cast in and
+	// matched literally, as the index expression and predicate require
+	dims := len(embedding)
+	distance := fmt.Sprintf("c.chunk_embedding::vector(%[1]d) <=> %[2]s::float8[]::vector(%[1]d)", dims, args.add(vectorParam(embedding)))
+	where := fmt.Sprintf("c.embedding_model = %s AND c.embedding_dimensions = %d", args.add(model), dims)
+	where += filterConditions(filter, "1 - ("+distance+")", &args)
+	query := fmt.Sprintf(`
+		SELECT 1 - (%[1]s), 0::float8, %[2]s
+		FROM "VideoChunk" c
+		JOIN "Video" v ON v.id = c.video_id
+		WHERE %[3]s
+		ORDER BY %[1]s
+		LIMIT %[4]s
+	`, distance, searchColumns, where, args.add(limit))
+
+	return r.search(ctx, query, model, args)
+}
+
+// KeywordSearch returns the chunks whose words match the query, best
+// full-text rank first. The query accepts web search syntax: "quoted
+// phrases", OR and -excluded words. When embedding is set the results also
+// carry their similarity to it; otherwise similarity is 0 and the filter's
+// MinSimilarity is ignored. Only chunks from model are searched, so each
+// chunk is counted once while a video has vectors from several models.
+func (r *SearchRepository) KeywordSearch(ctx context.Context, text string, embedding []float32, model string, filter models.SearchFilter, limit int) ([]models.SearchResult, error) {
+	var args queryArgs
+	tsquery := args.add(text)
+	where := "c.chunk_tsv @@ q AND c.embedding_model = " + args.add(model)
+	similarity := "0::float8"
+	if embedding != nil {
+		dims := len(embedding)
+		similarity = fmt.Sprintf("1 - (c.chunk_embedding::vector(%[1]d) <=> %[2]s::float8[]::vector(%[1]d))", dims, args.add(vectorParam(embedding)))
+		where += fmt.Sprintf(" AND c.embedding_dimensions = %d", dims)
+	} else {
+		filter.MinSimilarity = 0
+	}
+	where += filterConditions(filter, similarity, &args)
+	query := fmt.Sprintf(`
+		SELECT %s, ts_rank_cd(c.chunk_tsv, q, %d) AS rank, %s
+		FROM "VideoChunk" c
+		JOIN "Video" v ON v.id = c.video_id
+		CROSS JOIN websearch_to_tsquery('simple', %s) q
+		WHERE %s
+		ORDER BY rank DESC, c.id
+		LIMIT %s
+	`, similarity, keywordRankNormalization, searchColumns, tsquery, where, args.add(limit))
+
+	return r.search(ctx, query, model, args)
+}
+
+// queryArgs collects the parameters of a query as it is built
+type queryArgs []any
+
+// add appends a parameter and returns its placeholder
+func (a *queryArgs) add(value any) string {
+	*a = append(*a, value)
+	return fmt.Sprintf("$%d", len(*a))
+}
+
+// filterConditions renders the set fields of filter as conditions on the
+// chunk c and its video v, each starting with AND. similarity is the SQL
+// expression MinSimilarity applies to.
+func filterConditions(filter models.SearchFilter, similarity string, args *queryArgs) string {
+	var conditions []string
+	if len(filter.VideoIDs) > 0 {
+		conditions = append(conditions, fmt.Sprintf("c.video_id = ANY(%s::text[])", args.add(pq.Array(filter.VideoIDs))))
+	}
+	if filter.UserID != "" {
+		conditions = append(conditions, fmt.Sprintf(`v."userId" = %s`, args.add(filter.UserID)))
+	}
+	if filter.ChannelID != "" {
+		conditions = append(conditions, fmt.Sprintf(`v."channelId" = %s`, args.add(filter.ChannelID)))
+	}
+	if filter.UploadedFrom != "" {
+		conditions = append(conditions, fmt.Sprintf(`v."uploadDate" >= %s::date`, args.add(filter.UploadedFrom)))
+	}
+	if filter.UploadedTo != "" {
+		conditions = append(conditions, fmt.Sprintf(`v."uploadDate" <= %s::date`, args.add(filter.UploadedTo)))
+	}
+	if filter.CreatedAfter != nil {
+		conditions = append(conditions, fmt.Sprintf(`v."createdAt" >= %s`, args.add(*filter.CreatedAfter)))
+	}
+	if filter.CreatedBefore != nil {
+		conditions = append(conditions, fmt.Sprintf(`v."createdAt" < %s`, args.add(*filter.CreatedBefore)))
+	}
+	if filter.Language != "" {
+		conditions = append(conditions, fmt.Sprintf(
+			`lower(%s) IN (lower(v."detectedLanguage"), lower(v."requestedLanguage"), lower(v.language))`,
+			args.add(filter.Language)))
+	}
+	if filter.MinSimilarity != 0 {
+		conditions = append(conditions, fmt.Sprintf("%s >= %s", similarity, args.add(filter.MinSimilarity)))
+	}
+
+	var clause strings.Builder
+	for _, condition := range conditions {
+		clause.WriteString(" AND ")
+		clause.WriteString(condition)
+	}
+	return clause.String()
+}
+
+// search runs a search query. When nothing matches because the chunks come
+// from other models it returns a ModelMismatchError.
+func (r *SearchRepository) search(ctx context.Context, query string, model string, args queryArgs) ([]models.SearchResult, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search query failed: %w", err)
+	}
+	defer rows.Close()
+
+	results, err := scanSearchResults(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		if err := r.checkModel(ctx, model); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func scanSearchResults(rows *sql.Rows) ([]models.SearchResult, error) {
+	results := []models.SearchResult{}
+	for rows.Next() {
+		var result models.SearchResult
+		var words, speakerNames []byte
+		if err := rows.Scan(
+			&result.Similarity,
+			&result.KeywordRank,
+			&result.ChunkID,
+			&result.VideoID,
+			&result.Title,
+			&result.ChunkText,
+			&result.StartTime,
+			&result.EndTime,
+			&words,
+			pq.Array(&result.Speakers),
+			&speakerNames,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		if words != nil {
+			if err := json.Unmarshal(words, &result.Words); err != nil {
+				return nil, fmt.Errorf("failed to decode chunk words: %w", err)
+			}
+		}
+		if speakerNames != nil {
+			var names map[string]string
+			if err := json.Unmarshal(speakerNames, &names); err != nil {
+				return nil, fmt.Errorf("failed to decode speaker names: %w", err)
+			}
+			for i, label := range result.Speakers {
+				result.Speakers[i] = models.SpeakerName(names, label)
+			}
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search results: %w", err)
+	}
+	return results, nil
+}
+
+// vectorParam converts []float32 to []float64 for PostgreSQL compatibility
+func vectorParam(embedding []float32) any {
+	embedding64 := make([]float64, len(embedding))
+	for i, v := range embedding {
+		embedding64[i] = float64(v)
+	}
+	return pq.Array(embedding64)
+}
+
+// checkModel explains an empty result: it returns a ModelMismatchError when
+// the chunks that exist come from other models
+func (r *SearchRepository) checkModel(ctx context.Context, model string) error {
+	rows, err := r.db.QueryContext(ctx, `SELECT DISTINCT COALESCE(embedding_model, '') FROM "VideoChunk" ORDER BY 1`)
+	if err != nil {
+		return fmt.Errorf("embedding model query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var available []string
+	for rows.Next() {
+		var m string
+		if err := rows.Scan(&m); err != nil {
+			return fmt.Errorf("failed to scan embedding model: %w", err)
+		}
+		if m == model {
+			return nil
+		}
+		available = append(available, m)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(available) == 0 {
+		return nil
+	}
+	return &ModelMismatchError{Model: model, Available: available}
+}
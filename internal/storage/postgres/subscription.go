@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+const subscriptionColumns = `
+	id, "sourceUrl", kind, title, "isSearchable", "pollIntervalMinutes", active,
+	"lastSeenVideoId", "lastPolledAt", "userId", "createdAt", "updatedAt"
+`
+
+type SubscriptionRepository struct {
+	db *sql.DB
+}
+
+func NewSubscriptionRepository(db *sql.DB) *SubscriptionRepository {
+	return &SubscriptionRepository{db: db}
+}
+
+func (r *SubscriptionRepository) Create(ctx context.Context, url string, kind string, req *models.SubscriptionRequest) (*models.Subscription, error) {
+	query := `
+		INSERT INTO "Subscription" (id, "sourceUrl", kind, "isSearchable", "pollIntervalMinutes", active, "userId", "createdAt", "updatedAt")
+		VALUES (gen_random_uuid(), $1, $2, COALESCE($3, false), COALESCE($4, $5), COALESCE($6, true), $7, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING ` + subscriptionColumns
+
+	userID := os.Getenv("VIDEO_OWNER_USER_ID")
+	if userID == "" {
+		return nil, fmt.Errorf("VIDEO_OWNER_USER_ID environment variable must be set")
+	}
+
+	return scanSubscription(r.db.QueryRowContext(ctx, query,
+		url,
+		kind,
+		req.IsSearchable,
+		req.PollIntervalMinutes,
+		models.DefaultPollIntervalMinutes,
+		req.Active,
+		userID,
+	))
+}
+
+func (r *SubscriptionRepository) List(ctx context.Context) ([]models.Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM "Subscription" ORDER BY "createdAt"`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("subscription query failed: %w", err)
+	}
+	defer rows.Close()
+
+	subscriptions := []models.Subscription{}
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, *subscription)
+	}
+	return subscriptions, rows.Err()
+}
+
+func (r *SubscriptionRepository) Get(ctx context.Context, id string) (*models.Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM "Subscription" WHERE id = $1`
+	return scanSubscription(r.db.QueryRowContext(ctx, query, id))
+}
+
+// Update changes the fields set in the request and returns sql.ErrNoRows when
+// the subscription doesn't exist
+func (r *SubscriptionRepository) Update(ctx context.Context, id string, req *models.SubscriptionRequest) (*models.Subscription, error) {
+	query := `
+		UPDATE "Subscription"
+		SET "isSearchable" = COALESCE($2, "isSearchable"),
+			"pollIntervalMinutes" = COALESCE($3, "pollIntervalMinutes"),
+			active = COALESCE($4, active),
+			"updatedAt" = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING ` + subscriptionColumns
+
+	return scanSubscription(r.db.QueryRowContext(ctx, query, id, req.IsSearchable, req.PollIntervalMinutes, req.Active))
+}
+
+// Delete removes the subscription and returns sql.ErrNoRows when it doesn't exist
+func (r *SubscriptionRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM "Subscription" WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("subscription delete failed: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ClaimDue returns an active subscription whose poll interval has passed and
+// stamps it as polled, so other workers skip it. It returns nil when nothing
+// is due.
+func (r *SubscriptionRepository) ClaimDue(ctx context.Context) (*models.Subscription, error) {
+	query := `
+		UPDATE "Subscription"
+		SET "lastPolledAt" = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM "Subscription"
+			WHERE active
+			AND ("lastPolledAt" IS NULL
+				OR "lastPolledAt" + make_interval(mins => "pollIntervalMinutes") <= CURRENT_TIMESTAMP)
+			ORDER BY "lastPolledAt" NULLS FIRST
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING ` + subscriptionColumns
+
+	subscription, err := scanSubscription(r.db.QueryRowContext(ctx, query))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return subscription, err
+}
+
+// MarkSeen records the newest video seen on the channel or playlist
+func (r *SubscriptionRepository) MarkSeen(ctx context.Context, id string, lastSeenVideoID string, title string) error {
+	const query = `
+		UPDATE "Subscription"
+		SET "lastSeenVideoId" = $2, title = COALESCE(NULLIF($3, ''), title), "updatedAt" = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, lastSeenVideoID, title); err != nil {
+		return fmt.Errorf("failed to update subscription: %w", err)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSubscription(row rowScanner) (*models.Subscription, error) {
+	var subscription models.Subscription
+	err := row.Scan(
+		&subscription.ID,
+		&subscription.SourceURL,
+		&subscription.Kind,
+		&subscription.Title,
+		&subscription.IsSearchable,
+		&subscription.PollIntervalMinutes,
+		&subscription.Active,
+		&subscription.LastSeenVideoID,
+		&subscription.LastPolledAt,
+		&subscription.UserID,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 
 	"github.com/lib/pq"
@@ -16,10 +17,32 @@ func NewTranscriptionRepository(db *sql.DB) *TranscriptionRepository {
 	return &TranscriptionRepository{db: db}
 }
 
-func (r *TranscriptionRepository) SaveChunks(videoID string, chunks []models.Chunk) error {
-	stmt, err := r.db.Prepare(`
-        INSERT INTO "VideoChunk" (video_id, chunk_text, chunk_embedding, chunk_start_time, chunk_end_time)
-        VALUES ($1, $2, $3::float8[], $4, $5)
+// SaveChunks replaces the video's chunks, so reprocessing a video never
+// leaves duplicates behind. Chunks embedded with other models are dropped too,
+// since they were cut from the old transcript.
+func (r *TranscriptionRepository) SaveChunks(videoID string, model string, chunks []models.Chunk) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM "VideoChunk" WHERE video_id = $1`, videoID); err != nil {
+		return fmt.Errorf("delete existing chunks failed: %w", err)
+	}
+	if err := insertChunks(tx, videoID, model, chunks); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertChunks stores chunks embedded with model, recording the model and the
+// size of its vectors so search never compares vectors of different models
+func insertChunks(tx *sql.Tx, videoID string, model string, chunks []models.Chunk) error {
+	stmt, err := tx.Prepare(`
+        INSERT INTO "VideoChunk" (video_id, chunk_text, chunk_embedding, chunk_start_time, chunk_end_time, words, speakers,
+                                  embedding_model, embedding_dimensions)
+        VALUES ($1, $2, $3::float8[], $4, $5, $6, $7, $8, $9)
     `)
 	if err != nil {
 		return fmt.Errorf("prepare statement failed: %w", err)
@@ -33,12 +56,24 @@ func (r *TranscriptionRepository) SaveChunks(videoID string, chunks []models.Chu
 			embedding64[i] = float64(v)
 		}
 
+		// Chunks without word timings store NULL
+		var words []byte
+		if len(chunk.Words) > 0 {
+			if words, err = json.Marshal(chunk.Words); err != nil {
+				return fmt.Errorf("failed to encode chunk words: %w", err)
+			}
+		}
+
 		_, err = stmt.Exec(
 			videoID,
 			chunk.Text,
 			pq.Array(embedding64),
 			chunk.StartTime.Seconds(),  // Convert Duration to seconds
 			chunk.EndTime.Seconds(),    // Convert Duration to seconds
+			words,
+			pq.Array(chunk.Speakers),
+			model,
+			len(chunk.Embedding),
 		)
 		if err != nil {
 			return fmt.Errorf("chunk insert failed: %w", err)
@@ -47,13 +82,22 @@ func (r *TranscriptionRepository) SaveChunks(videoID string, chunks []models.Chu
 	return nil
 }
 
-func (r *TranscriptionRepository) SaveFullTranscription(videoID string, transcription string) error {
+// SaveFullTranscription stores the transcript, its language and where it came
+// from, e.g. "transcription" or "manual_captions". The WebVTT rendering is
+// kept in transcription, which older readers of the table rely on.
+func (r *TranscriptionRepository) SaveFullTranscription(videoID string, transcription string, transcript *models.Transcript, source string) error {
 	const updateSQL = `
 		UPDATE "Video" 
-		SET transcription = $1, status = 'transcribed', "updatedAt" = CURRENT_TIMESTAMP 
-		WHERE id = $2
+		SET transcription = $1, "transcriptSource" = $2, "transcriptData" = $4, "detectedLanguage" = NULLIF($5, ''),
+			status = 'transcribed', "updatedAt" = CURRENT_TIMESTAMP 
+		WHERE id = $3
 	`
-	result, err := r.db.Exec(updateSQL, transcription, videoID)
+	data, err := json.Marshal(transcript)
+	if err != nil {
+		return fmt.Errorf("failed to encode transcript: %w", err)
+	}
+
+	result, err := r.db.Exec(updateSQL, transcription, source, videoID, data, transcript.Language)
 	if err != nil {
 		return fmt.Errorf("failed to execute update: %w", err)
 	}
@@ -70,6 +114,20 @@ func (r *TranscriptionRepository) SaveFullTranscription(videoID string, transcri
 	return nil
 }
 
+// SaveTranslation stores the English translation of the video's transcript
+func (r *TranscriptionRepository) SaveTranslation(videoID string, translation *models.Transcript) error {
+	const updateSQL = `
+		UPDATE "Video" 
+		SET "translationData" = $2, "updatedAt" = CURRENT_TIMESTAMP 
+		WHERE id = $1
+	`
+	data, err := json.Marshal(translation)
+	if err != nil {
+		return fmt.Errorf("failed to encode translation: %w", err)
+	}
+	return r.execVideoUpdate(updateSQL, videoID, data)
+}
+
 func (r *TranscriptionRepository) UpdateVideoStatus(videoID string, status string) error {
 	const updateSQL = `
 		UPDATE "Video" 
@@ -93,9 +151,59 @@ func (r *TranscriptionRepository) UpdateVideoStatus(videoID string, status strin
 	return nil
 }
 
+// StartVideoAttempt marks the video as processing and counts the attempt
+func (r *TranscriptionRepository) StartVideoAttempt(videoID string) error {
+	const updateSQL = `
+		UPDATE "Video" 
+		SET status = 'processing', attempts = attempts + 1, "updatedAt" = CURRENT_TIMESTAMP 
+		WHERE id = $1
+	`
+	return r.execVideoUpdate(updateSQL, videoID)
+}
+
+// RecordVideoFailure sets the video status and keeps the error for on-call
+func (r *TranscriptionRepository) RecordVideoFailure(videoID string, status string, errMsg string) error {
+	const updateSQL = `
+		UPDATE "Video" 
+		SET status = $2, "lastError" = $3, "updatedAt" = CURRENT_TIMESTAMP 
+		WHERE id = $1
+	`
+	return r.execVideoUpdate(updateSQL, videoID, status, errMsg)
+}
+
+func (r *TranscriptionRepository) MarkVideoCompleted(videoID string) error {
+	const updateSQL = `
+		UPDATE "Video" 
+		SET status = 'completed', "lastError" = NULL, "updatedAt" = CURRENT_TIMESTAMP 
+		WHERE id = $1
+	`
+	return r.execVideoUpdate(updateSQL, videoID)
+}
+
+// execVideoUpdate runs an UPDATE whose first parameter is the video ID and
+// checks that the video exists
+func (r *TranscriptionRepository) execVideoUpdate(updateSQL string, videoID string, args ...any) error {
+	result, err := r.db.Exec(updateSQL, append([]any{videoID}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("no video found with ID: %s", videoID)
+	}
+
+	return nil
+}
+
 func (r *TranscriptionRepository) GetByURL(videoURL string) (*models.Video, error) {
 	const query = `
-        SELECT id, "videoUrl", transcription, status, "isSearchable"
+        SELECT id, "videoUrl", transcription, "transcriptData", status, "isSearchable",
+               "requestedLanguage", "detectedLanguage"
         FROM "Video"
         WHERE "videoUrl" = $1
         AND transcription IS NOT NULL
@@ -104,39 +212,119 @@ func (r *TranscriptionRepository) GetByURL(videoURL string) (*models.Video, erro
     `
     
     var video models.Video
+    var transcript []byte
     err := r.db.QueryRow(query, videoURL).Scan(
         &video.ID,
         &video.VideoURL,
         &video.Transcription,
+        &transcript,
         &video.Status,
         &video.IsSearchable,
+        &video.RequestedLanguage,
+        &video.DetectedLanguage,
     )
     if err != nil {
         return nil, err
     }
     
+    if err := decodeTranscript(transcript, &video); err != nil {
+        return nil, err
+    }
     return &video, nil
 }
 
-func (r *TranscriptionRepository) UpdateVideoTitle(videoID string, title string) error {
+func (r *TranscriptionRepository) GetVideo(videoID string) (*models.Video, error) {
+	const query = `
+        SELECT id, "videoUrl", "sourceType", transcription, status, "isSearchable", attempts, "lastError",
+               "requestedLanguage", translate
+        FROM "Video"
+        WHERE id = $1
+    `
+
+	var video models.Video
+	err := r.db.QueryRow(query, videoID).Scan(
+		&video.ID,
+		&video.VideoURL,
+		&video.SourceType,
+		&video.Transcription,
+		&video.Status,
+		&video.IsSearchable,
+		&video.Attempts,
+		&video.LastError,
+		&video.RequestedLanguage,
+		&video.Translate,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &video, nil
+}
+
+// UpdateVideoMetadata stores the title and descriptive fields fetched from
+// yt-dlp. Empty values are stored as NULL.
+func (r *TranscriptionRepository) UpdateVideoMetadata(videoID string, metadata *models.VideoMetadata) error {
 	const updateSQL = `
 		UPDATE "Video" 
-		SET title = $1, "updatedAt" = CURRENT_TIMESTAMP 
-		WHERE id = $2
+		SET title = $2,
+			"durationSeconds" = NULLIF($3::float8, 0),
+			"channelName" = NULLIF($4, ''),
+			"channelId" = NULLIF($5, ''),
+			"uploadDate" = $6,
+			description = NULLIF($7, ''),
+			"thumbnailUrl" = NULLIF($8, ''),
+			tags = $9,
+			chapters = $10::jsonb,
+			language = NULLIF($11, ''),
+			"updatedAt" = CURRENT_TIMESTAMP 
+		WHERE id = $1
 	`
-	result, err := r.db.Exec(updateSQL, title, videoID)
-	if err != nil {
-		return fmt.Errorf("failed to update title: %w", err)
-	}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	var chapters sql.NullString
+	if len(metadata.Chapters) > 0 {
+		data, err := json.Marshal(metadata.Chapters)
+		if err != nil {
+			return fmt.Errorf("failed to encode chapters: %w", err)
+		}
+		chapters = sql.NullString{String: string(data), Valid: true}
 	}
 
-	if rows == 0 {
-		return fmt.Errorf("no video found with ID: %s", videoID)
+	return r.execVideoUpdate(updateSQL, videoID,
+		metadata.Title,
+		metadata.DurationSeconds,
+		metadata.ChannelName,
+		metadata.ChannelID,
+		metadata.UploadDate,
+		metadata.Description,
+		metadata.ThumbnailURL,
+		pq.Array(metadata.Tags),
+		chapters,
+		metadata.Language,
+	)
+}
+
+// decodeTranscript fills video.Transcript from the "transcriptData" column,
+// which is NULL for videos transcribed before it existed
+func decodeTranscript(data []byte, video *models.Video) error {
+	if data == nil {
+		return nil
+	}
+	video.Transcript = &models.Transcript{}
+	if err := json.Unmarshal(data, video.Transcript); err != nil {
+		return fmt.Errorf("failed to decode transcript: %w", err)
 	}
+	return nil
+}
 
+// decodeTranslation fills video.Translation from the "translationData"
+// column, which is NULL unless a translation was asked for
+func decodeTranslation(data []byte, video *models.Video) error {
+	if data == nil {
+		return nil
+	}
+	video.Translation = &models.Transcript{}
+	if err := json.Unmarshal(data, video.Translation); err != nil {
+		return fmt.Errorf("failed to decode translation: %w", err)
+	}
 	return nil
-} 
\ No newline at end of file
+}
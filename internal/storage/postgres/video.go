@@ -3,9 +3,13 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/lib/pq"
 	"jamesfarrell.me/youtube-to-text/internal/storage/models"
 )
 
@@ -19,8 +23,8 @@ func NewVideoRepository(db *sql.DB) *VideoRepository {
 
 func (r *VideoRepository) Create(ctx context.Context, video *models.VideoRequest) (string, error) {
 	const query = `
-		INSERT INTO "Video" (id, "videoUrl", slug, status, "isSearchable", "createdAt", "updatedAt", "userId")
-		VALUES (gen_random_uuid(), $1, $2, 'pending', $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $4)
+		INSERT INTO "Video" (id, "videoUrl", slug, status, "isSearchable", "createdAt", "updatedAt", "userId", "requestedLanguage", translate)
+		VALUES (gen_random_uuid(), $1, $2, 'pending', $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $4, NULLIF($5, ''), $6)
 		RETURNING id
 	`
 
@@ -35,32 +39,165 @@ func (r *VideoRepository) Create(ctx context.Context, video *models.VideoRequest
 		models.ExtractSlugFromURL(video.URL), 
 		video.IsSearchable,
 		userID,
+		video.Language,
+		video.Translate,
+	).Scan(&id)
+	return id, err
+}
+
+// CreateUpload inserts a video for a recording stored in the upload directory
+// under fileName
+func (r *VideoRepository) CreateUpload(ctx context.Context, fileName string, title string, isSearchable bool) (string, error) {
+	const query = `
+		INSERT INTO "Video" (id, "videoUrl", slug, status, "isSearchable", "createdAt", "updatedAt", "userId", title, "sourceType")
+		VALUES (gen_random_uuid(), $1, $2, 'pending', $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $4, NULLIF($5, ''), $6)
+		RETURNING id
+	`
+
+	userID := os.Getenv("VIDEO_OWNER_USER_ID")
+	if userID == "" {
+		return "", fmt.Errorf("VIDEO_OWNER_USER_ID environment variable must be set")
+	}
+
+	var id string
+	err := r.db.QueryRowContext(ctx, query,
+		models.UploadURLPrefix+fileName,
+		"upload-"+strings.TrimSuffix(fileName, filepath.Ext(fileName)),
+		isSearchable,
+		userID,
+		title,
+		models.SourceUpload,
 	).Scan(&id)
 	return id, err
 }
 
+// CreateIfNew inserts a pending YouTube video unless one with the slug is
+// already stored, and reports whether it did. It takes the same lock as
+// playlist imports, so the two never insert the same video twice.
+func (r *VideoRepository) CreateIfNew(ctx context.Context, url string, slug string, isSearchable bool) (bool, error) {
+	const query = `
+		INSERT INTO "Video" (id, "videoUrl", slug, status, "isSearchable", "createdAt", "updatedAt", "userId")
+		SELECT gen_random_uuid(), $1, $2, 'pending', $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $4
+		WHERE NOT EXISTS (SELECT 1 FROM "Video" WHERE slug = $2)
+	`
+
+	userID := os.Getenv("VIDEO_OWNER_USER_ID")
+	if userID == "" {
+		return false, fmt.Errorf("VIDEO_OWNER_USER_ID environment variable must be set")
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, lockVideoInserts); err != nil {
+		return false, fmt.Errorf("video insert lock failed: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, query, url, slug, isSearchable, userID)
+	if err != nil {
+		return false, fmt.Errorf("video insert failed: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit failed: %w", err)
+	}
+	return rows > 0, nil
+}
+
 func (r *VideoRepository) Get(ctx context.Context, id string) (*models.Video, error) {
 	const query = `
-		SELECT id, "videoUrl", transcription, status, "isSearchable", 
-			   "createdAt", "updatedAt", "userId"
+		SELECT id, "videoUrl", COALESCE(title, ''), slug, "sourceType", transcription, "transcriptData", "transcriptSource", status, "isSearchable", 
+			   "createdAt", "updatedAt", "userId", attempts, "lastError",
+			   "durationSeconds", "channelName", "channelId", "uploadDate", description,
+			   "thumbnailUrl", tags, chapters, language, "speakerNames",
+			   "requestedLanguage", "detectedLanguage", translate, "translationData"
 		FROM "Video"
 		WHERE id = $1
 	`
 
 	var video models.Video
+	var chapters, transcript, speakerNames, translation []byte
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&video.ID,
 		&video.VideoURL,
+		&video.Title,
+		&video.Slug,
+		&video.SourceType,
 		&video.Transcription,
+		&transcript,
+		&video.TranscriptSource,
 		&video.Status,
 		&video.IsSearchable,
 		&video.CreatedAt,
 		&video.UpdatedAt,
 		&video.UserID,
+		&video.Attempts,
+		&video.LastError,
+		&video.DurationSeconds,
+		&video.ChannelName,
+		&video.ChannelID,
+		&video.UploadDate,
+		&video.Description,
+		&video.ThumbnailURL,
+		pq.Array(&video.Tags),
+		&chapters,
+		&video.Language,
+		&speakerNames,
+		&video.RequestedLanguage,
+		&video.DetectedLanguage,
+		&video.Translate,
+		&translation,
 	)
 	if err != nil {
 		return nil, err
 	}
+
+	if speakerNames != nil {
+		if err := json.Unmarshal(speakerNames, &video.SpeakerNames); err != nil {
+			return nil, fmt.Errorf("failed to decode speaker names: %w", err)
+		}
+	}
+	if chapters != nil {
+		if err := json.Unmarshal(chapters, &video.Chapters); err != nil {
+			return nil, fmt.Errorf("failed to decode chapters: %w", err)
+		}
+	}
+	if err := decodeTranscript(transcript, &video); err != nil {
+		return nil, err
+	}
+	if err := decodeTranslation(translation, &video); err != nil {
+		return nil, err
+	}
 	return &video, nil
 }
 
+// SetSpeakerNames replaces the names given to the video's speaker labels.
+// It returns sql.ErrNoRows when the video doesn't exist.
+func (r *VideoRepository) SetSpeakerNames(ctx context.Context, id string, names map[string]string) error {
+	var data []byte
+	if len(names) > 0 {
+		var err error
+		if data, err = json.Marshal(names); err != nil {
+			return fmt.Errorf("failed to encode speaker names: %w", err)
+		}
+	}
+
+	result, err := r.db.ExecContext(ctx, `UPDATE "Video" SET "speakerNames" = $2 WHERE id = $1`, id, data)
+	if err != nil {
+		return fmt.Errorf("speaker names update failed: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package transcription
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Caption policies decide whether existing YouTube subtitles replace paid transcription
+const (
+	CaptionsNever  = "never"
+	CaptionsManual = "manual"
+	CaptionsAny    = "any"
+)
+
+// Transcript sources recorded on the video
+const (
+	SourceManualCaptions = "manual_captions"
+	SourceAutoCaptions   = "auto_captions"
+	SourceTranscription  = "transcription"
+)
+
+// FetchCaptions downloads the video's subtitles with yt-dlp and converts them
+// to our VTT form. Creator-uploaded captions are preferred; auto-generated ones
+// are only used under the "any" policy. It returns an empty transcript when
+// no track matches. Files are written next to basePath and removed afterwards.
+//
+// With a requested language only creator-uploaded tracks in that language
+// are used: YouTube machine-translates auto-generated captions into other
+// languages, and those would be saved as the transcript. The captions are
+// returned with the source and the language of their track.
+func (s *Service) FetchCaptions(youtubeURL string, basePath string, language string) (string, string, string, error) {
+	defer removeCaptionFiles(basePath)
+
+	languages := s.captionLanguages
+	if language != "" {
+		languages = language + "," + language + "-.*"
+	}
+
+	raw, trackLanguage, err := downloadSubtitles(youtubeURL, basePath, "--write-subs", languages)
+	if err != nil {
+		return "", "", "", err
+	}
+	if raw != "" {
+		vtt, err := captionsToVTT(raw, false)
+		return vtt, SourceManualCaptions, trackLanguage, err
+	}
+
+	if s.captionPolicy != CaptionsAny || language != "" {
+		return "", "", "", nil
+	}
+
+	raw, trackLanguage, err = downloadSubtitles(youtubeURL, basePath, "--write-auto-subs", languages)
+	if err != nil {
+		return "", "", "", err
+	}
+	if raw != "" {
+		vtt, err := captionsToVTT(raw, true)
+		return vtt, SourceAutoCaptions, trackLanguage, err
+	}
+	return "", "", "", nil
+}
+
+// downloadSubtitles runs yt-dlp with the given subtitle flag and returns the
+// first track matching languages with its language, or "" when the video has
+// none
+func downloadSubtitles(youtubeURL string, basePath string, subtitleFlag string, languages string) (string, string, error) {
+	cmd := exec.Command("yt-dlp",
+		"--skip-download",
+		subtitleFlag,
+		"--sub-langs", languages,
+		"--sub-format", "vtt",
+		"-o", basePath,
+		youtubeURL)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", "", ytDLPError("downloading captions", err, output)
+	}
+
+	tracks, err := filepath.Glob(basePath + "*.vtt")
+	if err != nil {
+		return "", "", fmt.Errorf("error finding captions: %w", err)
+	}
+	if len(tracks) == 0 {
+		return "", "", nil
+	}
+	sort.Strings(tracks)
+
+	data, err := os.ReadFile(tracks[0])
+	if err != nil {
+		return "", "", fmt.Errorf("error reading captions: %w", err)
+	}
+	return string(data), trackLanguage(basePath, tracks[0]), nil
+}
+
+// trackLanguage reads the language from a subtitle file yt-dlp wrote for
+// basePath, such as basePath.en-US.vtt. YouTube marks the original
+// auto-generated track with -orig, as in en-orig.
+func trackLanguage(basePath string, track string) string {
+	language := strings.TrimPrefix(strings.TrimSuffix(track, ".vtt"), basePath+".")
+	return strings.TrimSuffix(language, "-orig")
+}
+
+func removeCaptionFiles(basePath string) {
+	files, _ := filepath.Glob(basePath + "*.vtt")
+	for _, file := range files {
+		os.Remove(file)
+	}
+}
+
+var captionTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// captionsToVTT rewrites a subtitle file from YouTube into the plain VTT we
+// store: no header metadata, cue settings or inline tags. Auto-generated
+// captions scroll, repeating the previous line in every cue, so with rolling
+// set lines already shown are dropped.
+func captionsToVTT(raw string, rolling bool) (string, error) {
+	captions, err := ParseWebVTT(raw)
+	if err != nil {
+		return "", fmt.Errorf("error converting captions: %w", err)
+	}
+
+	// Cue identifiers, settings such as "align:start position:0%", and NOTE
+	// or STYLE blocks only matter for display, so they are dropped
+	var doc WebVTT
+	lastLine := ""
+	for _, cue := range captions.Cues() {
+		var text []string
+		for _, line := range strings.Split(cue.Text, "\n") {
+			line = strings.TrimSpace(captionTagPattern.ReplaceAllString(line, ""))
+			if line == "" || (rolling && line == lastLine) {
+				continue
+			}
+			text = append(text, line)
+			lastLine = line
+		}
+		if len(text) == 0 {
+			continue
+		}
+
+		doc.AddCue(VTTCue{Start: cue.Start, End: cue.End, Text: strings.Join(text, "\n")})
+	}
+
+	return doc.String(), nil
+}
@@ -0,0 +1,77 @@
+package transcription
+
+import (
+	"testing"
+	"time"
+)
+
+const autoCaptions = "WEBVTT\r\nKind: captions\r\nLanguage: en\r\n\r\n" +
+	"00:00:00.160 --> 00:00:03.110 align:start position:0%\r\n" +
+	" \r\n" +
+	"hello<00:00:00.480><c> everyone</c><00:00:00.799><c> and</c><00:00:01.040><c> welcome</c>\r\n\r\n" +
+	"00:00:03.110 --> 00:00:03.120 align:start position:0%\r\n" +
+	"hello everyone and welcome\r\n" +
+	" \r\n\r\n" +
+	"00:00:03.120 --> 00:00:06.070 align:start position:0%\r\n" +
+	"hello everyone and welcome\r\n" +
+	"to<00:00:03.360><c> the</c><00:00:03.520><c> show</c>\r\n"
+
+func TestCaptionsToVTT(t *testing.T) {
+	vtt, err := captionsToVTT(autoCaptions, true)
+	if err != nil {
+		t.Fatalf("captionsToVTT() error = %v", err)
+	}
+
+	entries, err := ParseVTT(vtt)
+	if err != nil {
+		t.Fatalf("ParseVTT() error = %v", err)
+	}
+
+	want := []struct {
+		start, end time.Duration
+		text       string
+	}{
+		{160 * time.Millisecond, 3110 * time.Millisecond, "hello everyone and welcome"},
+		{3120 * time.Millisecond, 6070 * time.Millisecond, "to the show"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d:\n%s", len(entries), len(want), vtt)
+	}
+	for i, w := range want {
+		if entries[i].Start != w.start || entries[i].End != w.end || entries[i].Text != w.text {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], w)
+		}
+	}
+}
+
+func TestCaptionsToVTTManual(t *testing.T) {
+	manual := "WEBVTT\n\n1\n00:00:01.000 --> 00:00:02.000\n<i>Yeah.</i>\n\n2\n00:00:02.000 --> 00:00:03.000\nYeah.\n"
+
+	vtt, err := captionsToVTT(manual, false)
+	if err != nil {
+		t.Fatalf("captionsToVTT() error = %v", err)
+	}
+	entries, err := ParseVTT(vtt)
+	if err != nil {
+		t.Fatalf("ParseVTT() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("got %d entries, want 2: repeated lines are kept in manual captions", len(entries))
+	}
+}
+
+func TestTrackLanguage(t *testing.T) {
+	tests := []struct {
+		track string
+		want  string
+	}{
+		{"temp/captions_1.en.vtt", "en"},
+		{"temp/captions_1.de-DE.vtt", "de-DE"},
+		{"temp/captions_1.en-orig.vtt", "en"},
+	}
+	for _, tt := range tests {
+		if got := trackLanguage("temp/captions_1", tt.track); got != tt.want {
+			t.Errorf("trackLanguage(%q) = %q, want %q", tt.track, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package transcription
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+// maxChunkChars caps the text of a single chunk (~500 chars reads as ~30s of speech)
+const maxChunkChars = 500
+
+// chunkEntries groups parsed cues into search chunks. Each chunk starts at the
+// start of its first cue and ends at the end of its last cue, so the stored
+// times point at the real moment in the video. Consecutive chunks share the
+// cues that fall within the trailing overlap window of the previous chunk.
+// Each chunk lists the speakers of its cues in the order they speak.
+func chunkEntries(entries []models.SRTEntry, maxDuration time.Duration, overlap time.Duration) []models.Chunk {
+	var chunks []models.Chunk
+
+	start := 0
+	for start < len(entries) {
+		end := start + 1
+		textLen := len(entries[start].Text)
+		for end < len(entries) {
+			next := entries[end]
+			if next.End-entries[start].Start > maxDuration || textLen+1+len(next.Text) > maxChunkChars {
+				break
+			}
+			textLen += 1 + len(next.Text)
+			end++
+		}
+
+		texts := make([]string, 0, end-start)
+		var speakers []string
+		for _, entry := range entries[start:end] {
+			texts = append(texts, strings.TrimSpace(entry.Text))
+			if entry.Speaker != "" && !slices.Contains(speakers, entry.Speaker) {
+				speakers = append(speakers, entry.Speaker)
+			}
+		}
+		chunks = append(chunks, models.Chunk{
+			Text:      strings.Join(texts, " "),
+			StartTime: entries[start].Start,
+			EndTime:   entries[end-1].End,
+			Speakers:  speakers,
+		})
+
+		if end == len(entries) {
+			break
+		}
+
+		// Begin the next chunk with the cues inside the overlap window, always
+		// moving forward by at least one cue
+		next := end
+		for next > start+1 && entries[next-1].Start >= entries[end-1].End-overlap {
+			next--
+		}
+		start = next
+	}
+
+	return chunks
+}
+
+// attachWords gives each chunk the transcript's words that start within it,
+// so search hits can point at the matching word rather than the chunk start
+func attachWords(chunks []models.Chunk, transcript *models.Transcript) {
+	var words []models.Word
+	for _, segment := range transcript.Segments {
+		words = append(words, segment.Words...)
+	}
+	if len(words) == 0 {
+		return
+	}
+
+	for i := range chunks {
+		for _, word := range words {
+			if word.Start >= chunks[i].StartTime && word.Start < chunks[i].EndTime {
+				chunks[i].Words = append(chunks[i].Words, word)
+			}
+		}
+	}
+}
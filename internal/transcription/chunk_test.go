@@ -0,0 +1,151 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"jamesfarrell.me/youtube-to-text/internal/embeddings"
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+func cue(start, end time.Duration, text string) models.SRTEntry {
+	return models.SRTEntry{Start: start, End: end, Text: text}
+}
+
+func TestChunkEntries(t *testing.T) {
+	entries := []models.SRTEntry{
+		cue(1*time.Second, 9*time.Second, "first"),
+		cue(10*time.Second, 19*time.Second, "second"),
+		cue(20*time.Second, 29*time.Second, "third"),
+		cue(30*time.Second, 39*time.Second, "fourth"),
+		cue(40*time.Second, 49*time.Second, "fifth"),
+	}
+
+	tests := []struct {
+		name    string
+		max     time.Duration
+		overlap time.Duration
+		want    []models.Chunk
+	}{
+		{
+			name: "no overlap",
+			max:  30 * time.Second,
+			want: []models.Chunk{
+				{Text: "first second third", StartTime: 1 * time.Second, EndTime: 29 * time.Second},
+				{Text: "fourth fifth", StartTime: 30 * time.Second, EndTime: 49 * time.Second},
+			},
+		},
+		{
+			name:    "overlap carries trailing cues",
+			max:     30 * time.Second,
+			overlap: 10 * time.Second,
+			want: []models.Chunk{
+				{Text: "first second third", StartTime: 1 * time.Second, EndTime: 29 * time.Second},
+				{Text: "third fourth fifth", StartTime: 20 * time.Second, EndTime: 49 * time.Second},
+			},
+		},
+		{
+			name:    "overlap never stalls",
+			max:     5 * time.Second,
+			overlap: time.Minute,
+			want: []models.Chunk{
+				{Text: "first", StartTime: 1 * time.Second, EndTime: 9 * time.Second},
+				{Text: "second", StartTime: 10 * time.Second, EndTime: 19 * time.Second},
+				{Text: "third", StartTime: 20 * time.Second, EndTime: 29 * time.Second},
+				{Text: "fourth", StartTime: 30 * time.Second, EndTime: 39 * time.Second},
+				{Text: "fifth", StartTime: 40 * time.Second, EndTime: 49 * time.Second},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := chunkEntries(entries, tt.max, tt.overlap)
+			if len(got) != len(tt.want) {
+				t.Fatalf("chunkEntries() got %d chunks, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i].Text != tt.want[i].Text || got[i].StartTime != tt.want[i].StartTime || got[i].EndTime != tt.want[i].EndTime {
+					t.Errorf("chunk %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestChunkEntriesCharacterLimit(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	entries := []models.SRTEntry{
+		cue(0, time.Second, long),
+		cue(time.Second, 2*time.Second, long),
+	}
+
+	got := chunkEntries(entries, time.Minute, 0)
+	if len(got) != 2 {
+		t.Fatalf("chunkEntries() got %d chunks, want 2", len(got))
+	}
+	if got[1].StartTime != time.Second {
+		t.Errorf("second chunk starts at %v, want %v", got[1].StartTime, time.Second)
+	}
+}
+
+func TestChunkEntriesSpeakers(t *testing.T) {
+	entries := []models.SRTEntry{
+		{Start: 0, End: time.Second, Text: "Hi", Speaker: "SPEAKER_01"},
+		{Start: time.Second, End: 2 * time.Second, Text: "Hello", Speaker: "SPEAKER_00"},
+		{Start: 2 * time.Second, End: 3 * time.Second, Text: "Welcome", Speaker: "SPEAKER_01"},
+	}
+
+	got := chunkEntries(entries, time.Minute, 0)
+	if len(got) != 1 || !reflect.DeepEqual(got[0].Speakers, []string{"SPEAKER_01", "SPEAKER_00"}) {
+		t.Errorf("chunkEntries() = %+v, want both speakers in order", got)
+	}
+}
+
+func TestAttachWords(t *testing.T) {
+	transcript := &models.Transcript{Segments: []models.Segment{
+		{Start: 0, End: 2 * time.Second, Text: "one two", Words: []models.Word{
+			{Start: 0, End: time.Second, Text: "one"},
+			{Start: time.Second, End: 2 * time.Second, Text: "two"},
+		}},
+		{Start: 2 * time.Second, End: 3 * time.Second, Text: "three", Words: []models.Word{
+			{Start: 2 * time.Second, End: 3 * time.Second, Text: "three"},
+		}},
+	}}
+	chunks := []models.Chunk{
+		{StartTime: 0, EndTime: 2 * time.Second},
+		{StartTime: time.Second, EndTime: 3 * time.Second},
+	}
+
+	attachWords(chunks, transcript)
+	if got := chunks[0].Words; len(got) != 2 || got[1].Text != "two" {
+		t.Errorf("first chunk words = %+v, want one and two", got)
+	}
+	if got := chunks[1].Words; len(got) != 2 || got[0].Text != "two" || got[1].Text != "three" {
+		t.Errorf("overlapping chunk words = %+v, want two and three", got)
+	}
+}
+
+func TestEmbedChunks(t *testing.T) {
+	chunks := []models.Chunk{{Text: "first chunk"}, {Text: "second chunk"}}
+	embedder := embeddings.NewHashEmbedder(8)
+	if err := embedChunks(context.Background(), embedder, RetryPolicy{MaxAttempts: 1}, chunks); err != nil {
+		t.Fatalf("embedChunks() error = %v", err)
+	}
+	for i, chunk := range chunks {
+		want, _ := embeddings.Embed(context.Background(), embedder, chunk.Text)
+		if !reflect.DeepEqual(chunk.Embedding, want) {
+			t.Errorf("chunk %d embedding = %v, want %v", i, chunk.Embedding, want)
+		}
+	}
+
+	var permanent *PermanentError
+	err := embedChunks(context.Background(), nil, RetryPolicy{MaxAttempts: 3}, chunks)
+	if !errors.As(err, &permanent) {
+		t.Errorf("embedChunks() without an embedder error = %v, want a permanent error", err)
+	}
+}
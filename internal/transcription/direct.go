@@ -0,0 +1,149 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+// DownloadDirect fetches audio from a plain HTTP URL, such as a podcast
+// enclosure, without going through yt-dlp. Audio that isn't already mp3 is
+// converted with ffmpeg, then split like DownloadAudio. Files larger than
+// maxDownloadBytes are rejected rather than allowed to fill the disk.
+func (s *Service) DownloadDirect(ctx context.Context, audioURL string, outputPath string) error {
+	segmentDir := outputPath + "_segments"
+	if err := os.MkdirAll(segmentDir, 0755); err != nil {
+		return fmt.Errorf("error creating segments directory: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, audioURL, nil)
+	if err != nil {
+		return Permanent(fmt.Errorf("error creating download request: %w", err))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error downloading audio: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if s.maxDownloadBytes > 0 && resp.ContentLength > s.maxDownloadBytes {
+		return Permanent(fmt.Errorf("audio is %d bytes, over the %d byte download limit", resp.ContentLength, s.maxDownloadBytes))
+	}
+
+	downloadPath := outputPath + ".download"
+	defer os.Remove(downloadPath)
+	if err := saveBody(resp.Body, downloadPath, s.maxDownloadBytes); err != nil {
+		return err
+	}
+
+	if isMP3(resp.Header.Get("Content-Type"), audioURL) {
+		if err := os.Rename(downloadPath, outputPath); err != nil {
+			return fmt.Errorf("error moving downloaded audio: %w", err)
+		}
+	} else if err := convertToMP3(downloadPath, outputPath); err != nil {
+		return err
+	}
+
+	return s.prepareSegments(outputPath, segmentDir)
+}
+
+// PrepareUpload normalizes an uploaded recording with ffmpeg, whatever its
+// container, and splits it like DownloadAudio. The upload itself is kept.
+func (s *Service) PrepareUpload(uploadURL string, outputPath string) error {
+	segmentDir := outputPath + "_segments"
+	if err := os.MkdirAll(segmentDir, 0755); err != nil {
+		return fmt.Errorf("error creating segments directory: %w", err)
+	}
+
+	name := strings.TrimPrefix(uploadURL, models.UploadURLPrefix)
+	if name == uploadURL || name != filepath.Base(name) {
+		return Permanent(fmt.Errorf("invalid upload URL: %s", uploadURL))
+	}
+	uploadPath := filepath.Join(s.uploadDir, name)
+	if _, err := os.Stat(uploadPath); err != nil {
+		return Permanent(fmt.Errorf("upload not found: %w", err))
+	}
+
+	if err := convertToMP3(uploadPath, outputPath); err != nil {
+		return err
+	}
+	return s.prepareSegments(outputPath, segmentDir)
+}
+
+// saveBody writes body to path, failing once it exceeds maxBytes when that is
+// positive. Servers may send no length, or the wrong one, so the limit is
+// checked while copying too.
+func saveBody(body io.Reader, path string, maxBytes int64) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating download file: %w", err)
+	}
+	if maxBytes > 0 {
+		body = io.LimitReader(body, maxBytes+1)
+	}
+	n, err := io.Copy(file, body)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("error downloading audio: %w", err)
+	}
+	if maxBytes > 0 && n > maxBytes {
+		file.Close()
+		return Permanent(fmt.Errorf("audio is over the %d byte download limit", maxBytes))
+	}
+	return file.Close()
+}
+
+// isMP3 decides from the response's content type, or failing that the URL's
+// extension, whether the download can skip conversion
+func isMP3(contentType string, audioURL string) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch mediaType {
+		case "audio/mpeg", "audio/mp3":
+			return true
+		case "application/octet-stream", "binary/octet-stream":
+			// Generic types say nothing, so check the extension
+		default:
+			return false
+		}
+	}
+
+	parsed, err := url.Parse(audioURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(path.Ext(parsed.Path), ".mp3")
+}
+
+// convertToMP3 re-encodes any audio or video file ffmpeg can read, dropping
+// the video stream
+func convertToMP3(inputPath string, outputPath string) error {
+	cmd := exec.Command("ffmpeg",
+		"-hide_banner", "-y",
+		"-i", inputPath,
+		"-vn",
+		"-codec:a", "libmp3lame",
+		"-q:a", "2",
+		outputPath)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		// ffmpeg fails the same way on every attempt for files it can't read
+		return Permanent(fmt.Errorf("error converting audio: %w, output: %s", err, strings.TrimSpace(string(output))))
+	}
+	return nil
+}
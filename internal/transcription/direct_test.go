@@ -0,0 +1,94 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadDirect(t *testing.T) {
+	audio := []byte("ID3 fake mp3 data")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/episode.mp3" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Write(audio)
+	}))
+	defer server.Close()
+
+	s := &Service{}
+	outputPath := filepath.Join(t.TempDir(), "temp_episode.mp3")
+	if err := s.DownloadDirect(context.Background(), server.URL+"/episode.mp3", outputPath); err != nil {
+		t.Fatalf("DownloadDirect() error = %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(outputPath+"_segments", "segment_000.mp3"))
+	if err != nil {
+		t.Fatalf("segment not written: %v", err)
+	}
+	if string(got) != string(audio) {
+		t.Errorf("segment = %q, want downloaded audio", got)
+	}
+
+	err = s.DownloadDirect(context.Background(), server.URL+"/missing.mp3", outputPath)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("DownloadDirect() on missing file error = %v, want 404 StatusError", err)
+	}
+	if IsRetryable(err) {
+		t.Error("missing file should not be retried")
+	}
+}
+
+func TestIsMP3(t *testing.T) {
+	tests := []struct {
+		contentType string
+		url         string
+		want        bool
+	}{
+		{"audio/mpeg", "https://cdn.example.com/ep", true},
+		{"audio/mpeg; charset=binary", "https://cdn.example.com/ep", true},
+		{"audio/x-m4a", "https://cdn.example.com/ep.mp3", false},
+		{"application/octet-stream", "https://cdn.example.com/ep.MP3?token=1", true},
+		{"", "https://cdn.example.com/ep.mp3", true},
+		{"", "https://cdn.example.com/ep.m4a", false},
+	}
+
+	for _, tt := range tests {
+		if got := isMP3(tt.contentType, tt.url); got != tt.want {
+			t.Errorf("isMP3(%q, %q) = %v, want %v", tt.contentType, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadDirectLimit(t *testing.T) {
+	audio := []byte("ID3 fake mp3 data")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/mpeg")
+		if r.URL.Path == "/stream.mp3" {
+			// Flushing first sends the body chunked, without a length
+			w.(http.Flusher).Flush()
+		}
+		w.Write(audio)
+	}))
+	defer server.Close()
+
+	s := &Service{maxDownloadBytes: int64(len(audio) - 1)}
+	for _, path := range []string{"/episode.mp3", "/stream.mp3"} {
+		err := s.DownloadDirect(context.Background(), server.URL+path, filepath.Join(t.TempDir(), "temp_episode.mp3"))
+		if err == nil || IsRetryable(err) {
+			t.Errorf("DownloadDirect(%s) error = %v, want a permanent error over the limit", path, err)
+		}
+	}
+
+	s.maxDownloadBytes = int64(len(audio))
+	if err := s.DownloadDirect(context.Background(), server.URL+"/stream.mp3", filepath.Join(t.TempDir(), "temp_episode.mp3")); err != nil {
+		t.Errorf("DownloadDirect() at the limit error = %v", err)
+	}
+}
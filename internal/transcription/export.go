@@ -0,0 +1,207 @@
+package transcription
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+// Transcript export formats
+const (
+	FormatVTT      = "vtt"
+	FormatSRT      = "srt"
+	FormatText     = "txt"
+	FormatJSON     = "json"
+	FormatMarkdown = "md"
+)
+
+const (
+	// DefaultParagraphPause is the pause between cues that starts a new
+	// paragraph in text and Markdown exports
+	DefaultParagraphPause = 2 * time.Second
+	// maxParagraphDuration ends a paragraph at the next sentence end once it
+	// runs this long, so talks without pauses still break up
+	maxParagraphDuration = time.Minute
+)
+
+// ExportOptions tune the exports. The subtitle and JSON formats always carry
+// every segment with its timing.
+type ExportOptions struct {
+	// Timestamps prefixes each paragraph, or each cue without paragraphs,
+	// with its start time
+	Timestamps bool
+	// Paragraphs joins cues into paragraphs at pauses of ParagraphPause or
+	// longer; otherwise each cue is written on its own line
+	Paragraphs     bool
+	ParagraphPause time.Duration
+	// Title is written as the Markdown heading when set
+	Title string
+	// WordTimestamps adds inline word timestamp tags to VTT cues when the
+	// transcript has word timings
+	WordTimestamps bool
+}
+
+// ExportContentTypes maps each export format to its Content-Type
+var ExportContentTypes = map[string]string{
+	FormatVTT:      "text/vtt; charset=utf-8",
+	FormatSRT:      "application/x-subrip; charset=utf-8",
+	FormatText:     "text/plain; charset=utf-8",
+	FormatJSON:     "application/json",
+	FormatMarkdown: "text/markdown; charset=utf-8",
+}
+
+// Export renders a transcript in the given format
+func Export(transcript *models.Transcript, format string, opts ExportOptions) (string, error) {
+	entries := transcript.Entries()
+	switch format {
+	case FormatVTT:
+		if opts.WordTimestamps {
+			return writeKaraokeVTT(transcript), nil
+		}
+		return writeVTT(entries), nil
+	case FormatSRT:
+		return writeSRT(entries), nil
+	case FormatText:
+		return writeText(entries, opts), nil
+	case FormatJSON:
+		return writeJSON(transcript)
+	case FormatMarkdown:
+		return writeMarkdown(entries, opts), nil
+	default:
+		return "", fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// writeSRT numbers the entries from 1 and uses SRT's comma before
+// milliseconds. SRT has no voice markup, so speakers prefix the text.
+func writeSRT(entries []models.SRTEntry) string {
+	var b strings.Builder
+	for i, entry := range entries {
+		text := entry.Text
+		if entry.Speaker != "" {
+			text = entry.Speaker + ": " + text
+		}
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n",
+			i+1,
+			strings.Replace(formatTimestamp(entry.Start), ".", ",", 1),
+			strings.Replace(formatTimestamp(entry.End), ".", ",", 1),
+			text)
+	}
+	return b.String()
+}
+
+// writeJSON writes the transcript with its words and confidence, times in
+// seconds from the start of the video
+func writeJSON(transcript *models.Transcript) (string, error) {
+	data, err := json.MarshalIndent(transcript, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error encoding transcript: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+func writeText(entries []models.SRTEntry, opts ExportOptions) string {
+	var b strings.Builder
+	for _, p := range paragraphs(entries, opts) {
+		if opts.Timestamps {
+			fmt.Fprintf(&b, "[%s] ", formatClock(p.start))
+		}
+		if p.speaker != "" {
+			fmt.Fprintf(&b, "%s: ", p.speaker)
+		}
+		b.WriteString(p.text)
+		b.WriteString(paragraphSeparator(opts))
+	}
+	return b.String()
+}
+
+func writeMarkdown(entries []models.SRTEntry, opts ExportOptions) string {
+	var b strings.Builder
+	if opts.Title != "" {
+		fmt.Fprintf(&b, "# %s\n\n", opts.Title)
+	}
+	for _, p := range paragraphs(entries, opts) {
+		if opts.Timestamps {
+			fmt.Fprintf(&b, "**[%s]** ", formatClock(p.start))
+		}
+		if p.speaker != "" {
+			fmt.Fprintf(&b, "**%s:** ", p.speaker)
+		}
+		b.WriteString(p.text)
+		b.WriteString(paragraphSeparator(opts))
+	}
+	return b.String()
+}
+
+func paragraphSeparator(opts ExportOptions) string {
+	if opts.Paragraphs {
+		return "\n\n"
+	}
+	return "\n"
+}
+
+type paragraph struct {
+	start   time.Duration
+	speaker string
+	text    string
+}
+
+// paragraphs joins cue text, breaking at long pauses, when the speaker changes
+// and at the first sentence end after maxParagraphDuration. Without paragraphs
+// each cue stands alone.
+func paragraphs(entries []models.SRTEntry, opts ExportOptions) []paragraph {
+	pause := opts.ParagraphPause
+	if pause <= 0 {
+		pause = DefaultParagraphPause
+	}
+
+	var result []paragraph
+	var words []string
+	var start, previousEnd time.Duration
+	var speaker string
+	flush := func() {
+		if len(words) > 0 {
+			result = append(result, paragraph{start: start, speaker: speaker, text: strings.Join(words, " ")})
+			words = nil
+		}
+	}
+
+	for _, entry := range entries {
+		text := strings.Join(strings.Fields(entry.Text), " ")
+		if text == "" {
+			continue
+		}
+
+		if len(words) > 0 {
+			last := words[len(words)-1]
+			sentenceEnd := strings.HasSuffix(last, ".") || strings.HasSuffix(last, "?") || strings.HasSuffix(last, "!")
+			if !opts.Paragraphs ||
+				entry.Speaker != speaker ||
+				entry.Start-previousEnd >= pause ||
+				(sentenceEnd && entry.Start-start >= maxParagraphDuration) {
+				flush()
+			}
+		}
+		if len(words) == 0 {
+			start = entry.Start
+			speaker = entry.Speaker
+		}
+		words = append(words, text)
+		previousEnd = entry.End
+	}
+	flush()
+	return result
+}
+
+// formatClock writes a readable H:MM:SS or M:SS offset for text exports
+func formatClock(d time.Duration) string {
+	total := int(d / time.Second)
+	h, m, s := total/3600, total/60%60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
@@ -0,0 +1,202 @@
+package transcription
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+func exportEntries() []models.SRTEntry {
+	return []models.SRTEntry{
+		cue(0, 2*time.Second, "Hello and welcome."),
+		cue(2*time.Second, 4*time.Second, "Today we talk\nabout Go."),
+		cue(9*time.Second, 11500*time.Millisecond, "After a pause."),
+	}
+}
+
+func TestExportSRT(t *testing.T) {
+	got, err := Export(models.TranscriptFromEntries(exportEntries()[:2]), FormatSRT, ExportOptions{})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	want := "1\n00:00:00,000 --> 00:00:02,000\nHello and welcome.\n\n" +
+		"2\n00:00:02,000 --> 00:00:04,000\nToday we talk\nabout Go.\n\n"
+	if got != want {
+		t.Errorf("Export(srt) =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestExportVTTRoundTrip(t *testing.T) {
+	got, err := Export(models.TranscriptFromEntries(exportEntries()), FormatVTT, ExportOptions{})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	parsed, err := ParseVTT(got)
+	if err != nil {
+		t.Fatalf("ParseVTT() error = %v", err)
+	}
+	if len(parsed) != 3 || parsed[2].End != 11500*time.Millisecond {
+		t.Errorf("round trip = %+v", parsed)
+	}
+}
+
+func TestExportKaraokeVTT(t *testing.T) {
+	transcript := &models.Transcript{Segments: []models.Segment{
+		{Start: time.Second, End: 3 * time.Second, Text: "Hello <big> world", Words: []models.Word{
+			{Start: time.Second, End: 1500 * time.Millisecond, Text: " Hello"},
+			{Start: 1600 * time.Millisecond, End: 2 * time.Second, Text: "<big>"},
+			{Start: 1600 * time.Millisecond, End: 2 * time.Second, Text: "world"},
+		}},
+		{Start: 4 * time.Second, End: 5 * time.Second, Text: "No words here"},
+	}}
+
+	got, err := Export(transcript, FormatVTT, ExportOptions{WordTimestamps: true})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	want := "WEBVTT\n\n" +
+		"00:00:01.000 --> 00:00:03.000\nHello <00:00:01.600>&lt;big&gt; world\n\n" +
+		"00:00:04.000 --> 00:00:05.000\nNo words here\n\n"
+	if got != want {
+		t.Errorf("Export(vtt, words) =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func speakerTranscript() *models.Transcript {
+	return &models.Transcript{Segments: []models.Segment{
+		{Start: 0, End: time.Second, Text: "Welcome to the show.", Speaker: "SPEAKER_00"},
+		{Start: time.Second, End: 2 * time.Second, Text: "Let's begin.", Speaker: "SPEAKER_00"},
+		{Start: 2 * time.Second, End: 3 * time.Second, Text: "Thanks for having me.", Speaker: "SPEAKER_01"},
+	}}
+}
+
+func TestExportSpeakers(t *testing.T) {
+	transcript := speakerTranscript().WithSpeakerNames(map[string]string{"SPEAKER_00": "Ada <host>"})
+
+	vtt, err := Export(transcript, FormatVTT, ExportOptions{})
+	if err != nil {
+		t.Fatalf("Export(vtt) error = %v", err)
+	}
+	if !strings.Contains(vtt, "\n<v Ada &lt;host&gt;>Welcome to the show.\n") || !strings.Contains(vtt, "\n<v SPEAKER_01>Thanks for having me.\n") {
+		t.Errorf("Export(vtt) = %q, want voice spans", vtt)
+	}
+	entries, err := ParseVTT(vtt)
+	if err != nil {
+		t.Fatalf("ParseVTT() error = %v", err)
+	}
+	if entries[0].Speaker != "Ada <host>" || entries[0].Text != "Welcome to the show." {
+		t.Errorf("ParseVTT() = %+v, want the speaker split from the text", entries[0])
+	}
+
+	srt, err := Export(transcript, FormatSRT, ExportOptions{})
+	if err != nil {
+		t.Fatalf("Export(srt) error = %v", err)
+	}
+	if !strings.Contains(srt, "\nSPEAKER_01: Thanks for having me.\n") {
+		t.Errorf("Export(srt) = %q, want speaker prefixes", srt)
+	}
+
+	text, err := Export(transcript, FormatText, ExportOptions{Paragraphs: true})
+	if err != nil {
+		t.Fatalf("Export(txt) error = %v", err)
+	}
+	want := "Ada <host>: Welcome to the show. Let's begin.\n\nSPEAKER_01: Thanks for having me.\n\n"
+	if text != want {
+		t.Errorf("Export(txt) = %q, want %q", text, want)
+	}
+}
+
+func TestExportText(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ExportOptions
+		want string
+	}{
+		{
+			name: "line per cue",
+			opts: ExportOptions{},
+			want: "Hello and welcome.\nToday we talk about Go.\nAfter a pause.\n",
+		},
+		{
+			name: "paragraphs at pauses",
+			opts: ExportOptions{Paragraphs: true},
+			want: "Hello and welcome. Today we talk about Go.\n\nAfter a pause.\n\n",
+		},
+		{
+			name: "paragraphs with timestamps",
+			opts: ExportOptions{Paragraphs: true, Timestamps: true},
+			want: "[0:00] Hello and welcome. Today we talk about Go.\n\n[0:09] After a pause.\n\n",
+		},
+		{
+			name: "longer pause keeps one paragraph",
+			opts: ExportOptions{Paragraphs: true, ParagraphPause: 10 * time.Second},
+			want: "Hello and welcome. Today we talk about Go. After a pause.\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Export(models.TranscriptFromEntries(exportEntries()), FormatText, tt.opts)
+			if err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Export(txt) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportMarkdown(t *testing.T) {
+	got, err := Export(models.TranscriptFromEntries(exportEntries()), FormatMarkdown, ExportOptions{Title: "Episode 1", Paragraphs: true, Timestamps: true})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	want := "# Episode 1\n\n**[0:00]** Hello and welcome. Today we talk about Go.\n\n**[0:09]** After a pause.\n\n"
+	if got != want {
+		t.Errorf("Export(md) = %q, want %q", got, want)
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	got, err := Export(models.TranscriptFromEntries(exportEntries()), FormatJSON, ExportOptions{})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	var decoded models.Transcript
+	if err := json.Unmarshal([]byte(got), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(decoded.Segments) != 3 || decoded.Segments[2].Start != 9*time.Second || decoded.Segments[2].End != 11500*time.Millisecond {
+		t.Errorf("Export(json) = %+v", decoded.Segments)
+	}
+}
+
+func TestExportLongParagraphBreaksAtSentence(t *testing.T) {
+	var entries []models.SRTEntry
+	for i := 0; i < 8; i++ {
+		start := time.Duration(i) * 10 * time.Second
+		entries = append(entries, cue(start, start+10*time.Second, "Sentence."))
+	}
+
+	got := paragraphs(entries, ExportOptions{Paragraphs: true})
+	if len(got) != 2 || got[1].start != time.Minute {
+		t.Errorf("paragraphs() = %+v, want a break at the first sentence end after a minute", got)
+	}
+}
+
+func TestExportUnsupportedFormat(t *testing.T) {
+	if _, err := Export(models.TranscriptFromEntries(exportEntries()), "docx", ExportOptions{}); err == nil {
+		t.Error("Export(docx) returned no error")
+	}
+}
+
+func TestFormatClock(t *testing.T) {
+	if got := formatClock(time.Hour + 2*time.Minute + 3*time.Second); got != "1:02:03" {
+		t.Errorf("formatClock() = %q", got)
+	}
+}
@@ -0,0 +1,87 @@
+package transcription
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+// ytDLPInfo is the subset of `yt-dlp -J` output we keep
+type ytDLPInfo struct {
+	Title       string   `json:"title"`
+	Duration    float64  `json:"duration"`
+	Channel     string   `json:"channel"`
+	Uploader    string   `json:"uploader"`
+	ChannelID   string   `json:"channel_id"`
+	UploadDate  string   `json:"upload_date"` // YYYYMMDD
+	Description string   `json:"description"`
+	Thumbnail   string   `json:"thumbnail"`
+	Tags        []string `json:"tags"`
+	Language    string   `json:"language"`
+	Chapters    []struct {
+		Title     string  `json:"title"`
+		StartTime float64 `json:"start_time"`
+		EndTime   float64 `json:"end_time"`
+	} `json:"chapters"`
+}
+
+// FetchMetadata asks yt-dlp for the video's metadata in a single call
+func (s *Service) FetchMetadata(youtubeURL string) (*models.VideoMetadata, error) {
+	cmd := exec.Command("yt-dlp",
+		"-J",
+		"--no-playlist",
+		"--skip-download",
+		youtubeURL)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, ytDLPError("getting video metadata", err, stderr.Bytes())
+	}
+
+	return parseMetadata(output)
+}
+
+func parseMetadata(data []byte) (*models.VideoMetadata, error) {
+	var info ytDLPInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("error parsing video metadata: %w", err)
+	}
+
+	metadata := &models.VideoMetadata{
+		Title:           info.Title,
+		DurationSeconds: info.Duration,
+		ChannelName:     info.Channel,
+		ChannelID:       info.ChannelID,
+		Description:     info.Description,
+		ThumbnailURL:    info.Thumbnail,
+		Tags:            info.Tags,
+		Language:        info.Language,
+	}
+	if metadata.ChannelName == "" {
+		metadata.ChannelName = info.Uploader
+	}
+
+	if info.UploadDate != "" {
+		uploadDate, err := time.Parse("20060102", info.UploadDate)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing upload date %q: %w", info.UploadDate, err)
+		}
+		metadata.UploadDate = &uploadDate
+	}
+
+	for _, chapter := range info.Chapters {
+		metadata.Chapters = append(metadata.Chapters, models.Chapter{
+			Title:     chapter.Title,
+			StartTime: chapter.StartTime,
+			EndTime:   chapter.EndTime,
+		})
+	}
+
+	return metadata, nil
+}
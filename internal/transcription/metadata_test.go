@@ -0,0 +1,58 @@
+package transcription
+
+import (
+	"testing"
+	"time"
+)
+
+const metadataJSON = `{
+	"id": "wAzBl6xllzE",
+	"title": "Building a RAG pipeline",
+	"duration": 3723.5,
+	"channel": "Go Conf",
+	"channel_id": "UC123",
+	"uploader": "goconf",
+	"upload_date": "20240315",
+	"description": "Talk from the conference",
+	"thumbnail": "https://i.ytimg.com/vi/wAzBl6xllzE/maxresdefault.jpg",
+	"tags": ["go", "rag"],
+	"language": "en",
+	"chapters": [
+		{"start_time": 0, "end_time": 120.5, "title": "Intro"},
+		{"start_time": 120.5, "end_time": 3723.5, "title": "Demo"}
+	],
+	"formats": [{"format_id": "251"}]
+}`
+
+func TestParseMetadata(t *testing.T) {
+	metadata, err := parseMetadata([]byte(metadataJSON))
+	if err != nil {
+		t.Fatalf("parseMetadata() error = %v", err)
+	}
+
+	if metadata.Title != "Building a RAG pipeline" || metadata.DurationSeconds != 3723.5 {
+		t.Errorf("unexpected title/duration: %+v", metadata)
+	}
+	if metadata.ChannelName != "Go Conf" || metadata.ChannelID != "UC123" || metadata.Language != "en" {
+		t.Errorf("unexpected channel/language: %+v", metadata)
+	}
+	if metadata.UploadDate == nil || !metadata.UploadDate.Equal(time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("UploadDate = %v, want 2024-03-15", metadata.UploadDate)
+	}
+	if len(metadata.Tags) != 2 || metadata.Tags[1] != "rag" {
+		t.Errorf("Tags = %v", metadata.Tags)
+	}
+	if len(metadata.Chapters) != 2 || metadata.Chapters[1].Title != "Demo" || metadata.Chapters[1].StartTime != 120.5 {
+		t.Errorf("Chapters = %+v", metadata.Chapters)
+	}
+}
+
+func TestParseMetadataFallsBackToUploader(t *testing.T) {
+	metadata, err := parseMetadata([]byte(`{"title": "t", "uploader": "someone"}`))
+	if err != nil {
+		t.Fatalf("parseMetadata() error = %v", err)
+	}
+	if metadata.ChannelName != "someone" || metadata.UploadDate != nil {
+		t.Errorf("unexpected metadata: %+v", metadata)
+	}
+}
@@ -0,0 +1,71 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+
+	"jamesfarrell.me/youtube-to-text/internal/embeddings"
+	"jamesfarrell.me/youtube-to-text/internal/storage/postgres"
+)
+
+// ReindexOptions selects the videos to re-embed
+type ReindexOptions struct {
+	// VideoIDs limits the run to these videos; empty means every chunked video
+	VideoIDs []string
+	// All re-embeds videos that already have vectors from the model, rather
+	// than only the ones still missing them
+	All bool
+}
+
+type ReindexResult struct {
+	Videos int
+	Chunks int
+	Failed []string
+}
+
+// Reindex embeds the chunks of the selected videos with embedder and stores
+// them next to the vectors from other models, which keep serving searches
+// until the new model is deployed. A video that fails is reported and
+// skipped, so rerunning picks up where the last run stopped.
+func Reindex(ctx context.Context, repo *postgres.ChunkRepository, embedder embeddings.Embedder, opts ReindexOptions) (ReindexResult, error) {
+	var result ReindexResult
+	model := embedder.Model()
+
+	videoIDs, err := repo.VideosToReindex(ctx, model, opts.VideoIDs, opts.All)
+	if err != nil {
+		return result, err
+	}
+	fmt.Printf("Reindexing %d videos with %s\n", len(videoIDs), model)
+
+	policies := DefaultRetryPolicies()
+	for i, videoID := range videoIDs {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		chunks, err := repo.SourceChunks(ctx, videoID)
+		if err != nil {
+			return result, err
+		}
+		if len(chunks) == 0 {
+			continue
+		}
+
+		err = embedChunks(ctx, embedder, policies.Embed, chunks)
+		if err == nil {
+			err = retry(ctx, "Saving chunks", policies.Save, func() error {
+				return repo.ReplaceModelChunks(ctx, videoID, model, chunks)
+			})
+		}
+		if err != nil {
+			fmt.Printf("Failed to reindex video %s: %v\n", videoID, err)
+			result.Failed = append(result.Failed, videoID)
+			continue
+		}
+
+		result.Videos++
+		result.Chunks += len(chunks)
+		fmt.Printf("[%d/%d] Reindexed video %s (%d chunks)\n", i+1, len(videoIDs), videoID, len(chunks))
+	}
+	return result, nil
+}
@@ -0,0 +1,172 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// StatusError is returned when a provider answers with a non-200 status
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d, body: %s", e.StatusCode, e.Body)
+}
+
+// PermanentError marks a failure that retrying cannot fix, such as a private
+// video or an unsupported URL
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent wraps err so IsRetryable reports false for it
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsRetryable reports whether a failed stage is worth trying again. Rate
+// limits, server errors and network failures are retryable; permanent errors,
+// other 4xx responses and cancellation are not. Anything unclassified is
+// retried, bounded by the policy's attempt limit.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var permanent *PermanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return retryableStatus(statusErr.StatusCode)
+	}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return retryableStatus(apiErr.HTTPStatusCode)
+	}
+	var requestErr *openai.RequestError
+	if errors.As(err, &requestErr) {
+		return retryableStatus(requestErr.HTTPStatusCode)
+	}
+
+	// Network failures land here too: they carry no status to classify by
+	return true
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests ||
+		code == http.StatusRequestTimeout ||
+		code >= http.StatusInternalServerError
+}
+
+// permanentYTDLPErrors are yt-dlp messages for videos that will never download
+var permanentYTDLPErrors = []string{
+	"Private video",
+	"Video unavailable",
+	"This video has been removed",
+	"This video is not available",
+	"Unsupported URL",
+	"is not a valid URL",
+	"members-only",
+	"Join this channel to get access",
+	"Sign in to confirm your age",
+	"This live event will begin",
+	"copyright claim",
+}
+
+// ytDLPError wraps a yt-dlp failure, marking it permanent when the output says
+// the video can't be fetched at all
+func ytDLPError(action string, err error, output []byte) error {
+	wrapped := fmt.Errorf("error %s: %w, output: %s", action, err, strings.TrimSpace(string(output)))
+	for _, marker := range permanentYTDLPErrors {
+		if strings.Contains(string(output), marker) {
+			return Permanent(wrapped)
+		}
+	}
+	return wrapped
+}
+
+// RetryPolicy is an exponential backoff schedule
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Backoff returns the delay after the given (1-based) failed attempt
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return min(delay, p.MaxBackoff)
+}
+
+// RetryPolicies holds the in-process policy for each pipeline stage, and the
+// Job policy that reschedules a whole video once a stage gives up
+type RetryPolicies struct {
+	Download   RetryPolicy
+	Transcribe RetryPolicy
+	Embed      RetryPolicy
+	Save       RetryPolicy
+	Job        RetryPolicy
+}
+
+func DefaultRetryPolicies() RetryPolicies {
+	return RetryPolicies{
+		Download:   RetryPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Second, MaxBackoff: time.Minute},
+		Transcribe: RetryPolicy{MaxAttempts: 4, InitialBackoff: 5 * time.Second, MaxBackoff: time.Minute},
+		Embed:      RetryPolicy{MaxAttempts: 5, InitialBackoff: 2 * time.Second, MaxBackoff: 30 * time.Second},
+		Save:       RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: 10 * time.Second},
+		Job:        RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Minute, MaxBackoff: time.Hour},
+	}
+}
+
+// retry runs fn until it succeeds, fails with a non-retryable error, or the
+// policy runs out of attempts
+func retry(ctx context.Context, stage string, policy RetryPolicy, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if !IsRetryable(err) || attempt >= policy.MaxAttempts {
+			return err
+		}
+
+		delay := policy.Backoff(attempt)
+		fmt.Printf("%s failed (attempt %d/%d), retrying in %v: %v\n", stage, attempt, policy.MaxAttempts, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
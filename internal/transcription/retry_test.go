@@ -0,0 +1,80 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "rate limited", err: &StatusError{StatusCode: 429}, want: true},
+		{name: "server error", err: fmt.Errorf("segment 2: %w", &StatusError{StatusCode: 502}), want: true},
+		{name: "bad request", err: &StatusError{StatusCode: 400}, want: false},
+		{name: "network", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: true},
+		{name: "permanent", err: Permanent(errors.New("nope")), want: false},
+		{name: "private video", err: ytDLPError("downloading audio", errors.New("exit status 1"), []byte("ERROR: [youtube] abc: Private video")), want: false},
+		{name: "yt-dlp throttled", err: ytDLPError("downloading audio", errors.New("exit status 1"), []byte("ERROR: HTTP Error 429: Too Many Requests")), want: true},
+		{name: "cancelled", err: context.Canceled, want: false},
+		{name: "unclassified", err: errors.New("ffmpeg: exit status 1"), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := policy.Backoff(i + 1); got != w {
+			t.Errorf("Backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	calls := 0
+	err := retry(context.Background(), "test", policy, func() error {
+		calls++
+		if calls < 3 {
+			return &StatusError{StatusCode: 503}
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("retry() = %v after %d calls, want success after 3", err, calls)
+	}
+
+	calls = 0
+	err = retry(context.Background(), "test", policy, func() error {
+		calls++
+		return Permanent(errors.New("private video"))
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("retry() = %v after %d calls, want permanent error after 1", err, calls)
+	}
+
+	calls = 0
+	err = retry(context.Background(), "test", policy, func() error {
+		calls++
+		return &StatusError{StatusCode: 500}
+	})
+	if err == nil || calls != 3 {
+		t.Errorf("retry() = %v after %d calls, want error after 3", err, calls)
+	}
+}
@@ -0,0 +1,246 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+// probeDuration returns the duration of an audio file as reported by ffprobe
+func probeDuration(path string) (time.Duration, error) {
+	durationCmd := exec.Command("ffprobe",
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		path)
+
+	durationBytes, err := durationCmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("error getting audio duration: %w", err)
+	}
+
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(string(durationBytes)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing duration: %w", err)
+	}
+
+	return models.SecondsToDuration(seconds), nil
+}
+
+// segmentOffsets returns where each segment starts in the original audio,
+// using the real duration of every segment before it
+func segmentOffsets(segments []string) ([]time.Duration, error) {
+	offsets := make([]time.Duration, len(segments))
+	for i := 1; i < len(segments); i++ {
+		duration, err := probeDuration(segments[i-1])
+		if err != nil {
+			return nil, fmt.Errorf("segment %s: %w", segments[i-1], err)
+		}
+		offsets[i] = offsets[i-1] + duration
+	}
+	return offsets, nil
+}
+
+// transcribeSegments transcribes up to segmentConcurrency segments at once and
+// returns the transcript of each segment in the original order. The first
+// failure cancels the remaining segments.
+func (s *Service) transcribeSegments(ctx context.Context, segments []string, opts TranscribeOptions) ([]*models.Transcript, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*models.Transcript, len(segments))
+	limit := newStageLimiter(s.segmentConcurrency)
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	for i, segment := range segments {
+		if err := limit.acquire(ctx); err != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer limit.release()
+
+			err := retry(ctx, "Transcribing "+segment, s.retry.Transcribe, func() error {
+				var err error
+				results[i], err = s.transcriber.Transcribe(ctx, segment, opts)
+				return err
+			})
+			if err != nil {
+				fail(fmt.Errorf("error transcribing segment %s: %w", segment, err))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// segmentBounds returns where each segment's audio starts and ends in the
+// original audio. Segments split at silences record where they start and may
+// run into the next one, so their ends come from their real durations.
+// Otherwise each segment is offset by the real duration of the audio before
+// it, so silence at the end of a segment doesn't make later cues drift, and
+// ends where the next one starts. The end of the last segment isn't needed
+// and is left zero.
+func segmentBounds(segmentDir string, segments []string) ([]time.Duration, []time.Duration, error) {
+	ends := make([]time.Duration, len(segments))
+	offsets, err := readSegmentOffsets(segmentDir, len(segments))
+	if err != nil {
+		return nil, nil, err
+	}
+	if offsets == nil {
+		offsets, err = segmentOffsets(segments)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := 0; i < len(segments)-1; i++ {
+			ends[i] = offsets[i+1]
+		}
+		return offsets, ends, nil
+	}
+
+	for i := 0; i < len(segments)-1; i++ {
+		duration, err := probeDuration(segments[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("segment %s: %w", segments[i], err)
+		}
+		ends[i] = offsets[i] + duration
+	}
+	return offsets, ends, nil
+}
+
+// stitchSegments shifts each segment's transcript by the segment's offset and
+// joins them in order. Where a segment starts before the previous one's audio
+// ends (ends[i-1]), repeated speech and words at the start of the later
+// segment are dropped; segments that don't overlap are kept whole, however
+// the provider's timings drift. The language is taken from the first segment
+// that reports one.
+//
+// Speaker labels are kept as the provider gave them, although it diarizes
+// each segment on its own: SPEAKER_00 in one segment needn't be the same
+// person as SPEAKER_00 in the next.
+func stitchSegments(segments []*models.Transcript, offsets []time.Duration, ends []time.Duration) *models.Transcript {
+	stitched := &models.Transcript{Segments: []models.Segment{}}
+	for i, transcript := range segments {
+		if stitched.Language == "" {
+			stitched.Language = transcript.Language
+		}
+
+		shifted := make([]models.Segment, len(transcript.Segments))
+		for j, segment := range transcript.Segments {
+			shifted[j] = shiftSegment(segment, offsets[i])
+		}
+		if i > 0 && offsets[i] < ends[i-1] && len(stitched.Segments) > 0 {
+			shifted = reconcileOverlap(stitched.Segments, shifted)
+		}
+		stitched.Segments = append(stitched.Segments, shifted...)
+	}
+	return stitched
+}
+
+func shiftSegment(segment models.Segment, offset time.Duration) models.Segment {
+	segment.Start += offset
+	segment.End += offset
+	if segment.Words != nil {
+		words := make([]models.Word, len(segment.Words))
+		for i, word := range segment.Words {
+			word.Start += offset
+			word.End += offset
+			words[i] = word
+		}
+		segment.Words = words
+	}
+	return segment
+}
+
+// maxOverlapWords bounds how far back reconcileOverlap looks for repeated words
+const maxOverlapWords = 50
+
+// reconcileOverlap trims the segments of next that cover audio prev already
+// transcribed. Segments that end before prev's last segment are dropped, and
+// a segment straddling that boundary loses the leading words that repeat
+// prev's tail.
+func reconcileOverlap(prev []models.Segment, next []models.Segment) []models.Segment {
+	boundary := prev[len(prev)-1].End
+
+	var tail []string
+	for i := len(prev) - 1; i >= 0 && len(tail) < maxOverlapWords; i-- {
+		tail = append(strings.Fields(prev[i].Text), tail...)
+	}
+
+	var reconciled []models.Segment
+	for i, segment := range next {
+		if segment.Start >= boundary {
+			reconciled = append(reconciled, next[i:]...)
+			break
+		}
+		if segment.End <= boundary {
+			continue
+		}
+
+		words := strings.Fields(segment.Text)
+		repeated := overlapLength(tail, words)
+		if repeated == len(words) {
+			continue
+		}
+		segment.Text = strings.Join(words[repeated:], " ")
+		segment.Start = boundary
+		// Timed words line up with the text when the provider split them the
+		// same way; otherwise keep the words heard after the boundary
+		if len(segment.Words) == len(words) {
+			segment.Words = segment.Words[repeated:]
+		} else if segment.Words != nil {
+			var kept []models.Word
+			for _, word := range segment.Words {
+				if word.End > boundary {
+					kept = append(kept, word)
+				}
+			}
+			segment.Words = kept
+		}
+		reconciled = append(reconciled, segment)
+	}
+	return reconciled
+}
+
+// overlapLength returns the length of the longest run of words that ends tail
+// and starts words, ignoring case and punctuation
+func overlapLength(tail []string, words []string) int {
+	for n := min(len(tail), len(words)); n > 0; n-- {
+		match := true
+		for i := 0; i < n; i++ {
+			if models.NormalizeWord(tail[len(tail)-n+i]) != models.NormalizeWord(words[i]) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return n
+		}
+	}
+	return 0
+}
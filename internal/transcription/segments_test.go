@@ -0,0 +1,165 @@
+package transcription
+
+import (
+	"testing"
+	"time"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+func transcriptOf(entries ...models.SRTEntry) *models.Transcript {
+	return models.TranscriptFromEntries(entries)
+}
+
+func TestStitchSegments(t *testing.T) {
+	segments := []*models.Transcript{
+		transcriptOf(cue(0, 2*time.Second, "one"), cue(2*time.Second, 5*time.Second, "two")),
+		transcriptOf(cue(time.Second, 3*time.Second, "three")),
+		transcriptOf(),
+		transcriptOf(cue(0, time.Second, "four")),
+	}
+	// The first segment ends in silence: its real duration (10s) is longer
+	// than its last cue, so the next segment must start at 10s, not 5s
+	offsets := []time.Duration{0, 10 * time.Second, 20 * time.Second, 20 * time.Second}
+	ends := []time.Duration{10 * time.Second, 20 * time.Second, 20 * time.Second, 0}
+
+	got := stitchSegments(segments, offsets, ends).Entries()
+	want := []models.SRTEntry{
+		{Number: 1, Start: 0, End: 2 * time.Second, Text: "one"},
+		{Number: 2, Start: 2 * time.Second, End: 5 * time.Second, Text: "two"},
+		{Number: 3, Start: 11 * time.Second, End: 13 * time.Second, Text: "three"},
+		{Number: 4, Start: 20 * time.Second, End: 21 * time.Second, Text: "four"},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("stitchSegments() got %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestStitchSegmentsReconcilesOverlap(t *testing.T) {
+	segments := []*models.Transcript{
+		transcriptOf(
+			cue(0, 4*time.Second, "Welcome back to the show."),
+			cue(4*time.Second, 9500*time.Millisecond, "Today we are talking about"),
+		),
+		// The second segment starts at 8s, two seconds before the first one ends
+		transcriptOf(
+			cue(0, 1*time.Second, "talking"),
+			cue(1*time.Second, 4*time.Second, "about search engines and"),
+			cue(4*time.Second, 6*time.Second, "how they work."),
+		),
+	}
+	offsets := []time.Duration{0, 8 * time.Second}
+	ends := []time.Duration{10 * time.Second, 0}
+
+	got := stitchSegments(segments, offsets, ends).Entries()
+	want := []models.SRTEntry{
+		{Number: 1, Start: 0, End: 4 * time.Second, Text: "Welcome back to the show."},
+		{Number: 2, Start: 4 * time.Second, End: 9500 * time.Millisecond, Text: "Today we are talking about"},
+		{Number: 3, Start: 9500 * time.Millisecond, End: 12 * time.Second, Text: "search engines and"},
+		{Number: 4, Start: 12 * time.Second, End: 14 * time.Second, Text: "how they work."},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("stitchSegments() got %d entries, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestStitchSegmentsShiftsWords(t *testing.T) {
+	second := &models.Transcript{
+		Language: "english",
+		Segments: []models.Segment{{
+			Start: 0,
+			End:   2 * time.Second,
+			Text:  "about search",
+			Words: []models.Word{
+				{Start: 0, End: time.Second, Text: "about"},
+				{Start: time.Second, End: 2 * time.Second, Text: "search"},
+			},
+		}},
+	}
+	segments := []*models.Transcript{
+		transcriptOf(cue(0, 9*time.Second, "Today we are talking about")),
+		second,
+	}
+
+	got := stitchSegments(segments, []time.Duration{0, 8 * time.Second}, []time.Duration{10 * time.Second, 0})
+	if got.Language != "english" {
+		t.Errorf("Language = %q, want language of the first segment reporting one", got.Language)
+	}
+	if len(got.Segments) != 2 {
+		t.Fatalf("got %d segments, want 2", len(got.Segments))
+	}
+
+	words := got.Segments[1].Words
+	if len(words) != 1 || words[0].Text != "search" || words[0].Start != 9*time.Second {
+		t.Errorf("words = %+v, want the repeated word dropped and the rest shifted", words)
+	}
+	if second.Segments[0].Words[1].Start != time.Second {
+		t.Error("stitchSegments modified the input transcript")
+	}
+}
+
+func TestStitchSegmentsWithoutOverlapKeepsSpeech(t *testing.T) {
+	segments := []*models.Transcript{
+		// The provider stretches the last cue past the real end of the audio
+		transcriptOf(cue(0, 10500*time.Millisecond, "and that is how it works")),
+		// Nothing is heard twice: the second segment starts where the first
+		// ends, and happens to repeat the last words
+		transcriptOf(
+			cue(0, 300*time.Millisecond, "works"),
+			cue(300*time.Millisecond, 2*time.Second, "how it works in practice"),
+		),
+	}
+	offsets := []time.Duration{0, 10 * time.Second}
+	ends := []time.Duration{10 * time.Second, 0}
+
+	got := stitchSegments(segments, offsets, ends).Entries()
+	want := []string{"and that is how it works", "works", "how it works in practice"}
+	if len(got) != len(want) {
+		t.Fatalf("stitchSegments() got %d entries, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Text != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, got[i].Text, want[i])
+		}
+	}
+}
+
+func TestStitchSegmentsKeepsSpeakerLabels(t *testing.T) {
+	first := transcriptOf(cue(0, 2*time.Second, "Welcome to the show."), cue(2*time.Second, 4*time.Second, "Thanks for having me."))
+	first.Segments[0].Speaker = "SPEAKER_00"
+	first.Segments[1].Speaker = "SPEAKER_01"
+	// The provider labels the second segment afresh; its labels are kept as
+	// they are rather than renamed per segment
+	second := transcriptOf(cue(0, 2*time.Second, "So, where did it start?"), cue(2*time.Second, 3*time.Second, "Right."))
+	second.Segments[0].Speaker = "SPEAKER_00"
+
+	stitched := stitchSegments([]*models.Transcript{first, second}, []time.Duration{0, 10 * time.Second}, []time.Duration{10 * time.Second, 0})
+	var labels []string
+	for _, segment := range stitched.Segments {
+		labels = append(labels, segment.Speaker)
+	}
+	want := []string{"SPEAKER_00", "SPEAKER_01", "SPEAKER_00", ""}
+	if len(labels) != len(want) {
+		t.Fatalf("stitchSegments() speakers = %q, want %q", labels, want)
+	}
+	for i := range want {
+		if labels[i] != want[i] {
+			t.Errorf("segment %d speaker = %q, want %q", i, labels[i], want[i])
+		}
+	}
+	if got := stitched.Speakers(); len(got) != 2 {
+		t.Errorf("Speakers() = %q, want SPEAKER_00 and SPEAKER_01", got)
+	}
+}
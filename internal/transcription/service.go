@@ -1,19 +1,17 @@
 package transcription
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"mime/multipart"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/lib/pq"
+	"jamesfarrell.me/youtube-to-text/internal/config"
 	"jamesfarrell.me/youtube-to-text/internal/embeddings"
 	"jamesfarrell.me/youtube-to-text/internal/storage/models"
 	"jamesfarrell.me/youtube-to-text/internal/storage/postgres"
@@ -21,36 +19,58 @@ import (
 
 type Service struct {
 	transcriptionRepo *postgres.TranscriptionRepository
-	apiKey            string
+	jobRepo           *postgres.JobRepository
+	transcriber       Transcriber
+	embedder          embeddings.Embedder
 	dbURL             string
+	workerID          string
+
+	workers         int
+	wake            chan struct{}
+	downloadLimit   stageLimiter
+	transcribeLimit stageLimiter
+	embedLimit      stageLimiter
+
+	segmentConcurrency int
+	segmentOverlap     time.Duration
+	captionPolicy      string
+	captionLanguages   string
+	uploadDir          string
+	maxDownloadBytes   int64
+	retry              RetryPolicies
 }
 
-func NewService(repo *postgres.TranscriptionRepository, apiKey string, dbURL string) *Service {
+func NewService(repo *postgres.TranscriptionRepository, jobRepo *postgres.JobRepository, transcriber Transcriber, embedder embeddings.Embedder, dbURL string, workerCfg config.WorkerConfig) *Service {
+	workers := max(workerCfg.Workers, 1)
 	return &Service{
-		transcriptionRepo: repo,
-		apiKey:           apiKey,
-		dbURL:            dbURL,
+		transcriptionRepo:  repo,
+		jobRepo:            jobRepo,
+		transcriber:        transcriber,
+		embedder:           embedder,
+		dbURL:              dbURL,
+		workerID:           newWorkerID(),
+		workers:            workers,
+		wake:               make(chan struct{}, workers),
+		downloadLimit:      newStageLimiter(workerCfg.DownloadConcurrency),
+		transcribeLimit:    newStageLimiter(workerCfg.TranscribeConcurrency),
+		embedLimit:         newStageLimiter(workerCfg.EmbedConcurrency),
+		segmentConcurrency: workerCfg.SegmentConcurrency,
+		segmentOverlap:     workerCfg.SegmentOverlap,
+		captionPolicy:      workerCfg.CaptionPolicy,
+		captionLanguages:   workerCfg.CaptionLanguages,
+		uploadDir:          workerCfg.UploadDir,
+		maxDownloadBytes:   workerCfg.MaxDownloadBytes,
+		retry:              DefaultRetryPolicies(),
 	}
 }
 
-func (s *Service) DownloadAudio(youtubeURL string, outputPath string) (string, error) {
+func (s *Service) DownloadAudio(youtubeURL string, outputPath string) error {
 	// Create segments directory from the start
 	segmentDir := outputPath + "_segments"
 	if err := os.MkdirAll(segmentDir, 0755); err != nil {
-		return "", fmt.Errorf("error creating segments directory: %w", err)
+		return fmt.Errorf("error creating segments directory: %w", err)
 	}
 
-	// Add verbose output to help debug
-	titleCmd := exec.Command("yt-dlp",
-		"--get-title",
-		youtubeURL)
-	
-	titleBytes, err := titleCmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("error getting video title: %w, output: %s", err, string(titleBytes))
-	}
-	title := strings.TrimSpace(string(titleBytes))
-
 	// Check file size first by downloading to original path
 	cmd := exec.Command("yt-dlp",
 		"--extract-audio",
@@ -59,187 +79,63 @@ func (s *Service) DownloadAudio(youtubeURL string, outputPath string) (string, e
 		"-o", outputPath,
 		youtubeURL)
 
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("error downloading audio: %w", err)
-	}
-	
-	// Check file size
-	fileInfo, err := os.Stat(outputPath)
-	if err != nil {
-		return "", fmt.Errorf("error checking file size: %w", err)
-	}
-	
-	// 90MB is the max size for the audio file
-	const maxSize = 90 * 1024 * 1024 // 90MB in bytes
-	fmt.Printf("File size: %d bytes\n", fileInfo.Size())
-	if fileInfo.Size() > maxSize {
-		fmt.Println("Audio file too large, splitting...")
-		// Get duration of the audio file
-		durationCmd := exec.Command("ffprobe",
-			"-v", "error",
-			"-show_entries", "format=duration",
-			"-of", "default=noprint_wrappers=1:nokey=1",
-			outputPath)
-		
-		durationBytes, err := durationCmd.Output()
-		if err != nil {
-			return "", fmt.Errorf("error getting audio duration: %w", err)
-		}
-		
-		var duration float64
-		if _, err := fmt.Sscanf(string(durationBytes), "%f", &duration); err != nil {
-			return "", fmt.Errorf("error parsing duration: %w", err)
-		}
-
-		// Calculate number of segments needed (aim for ~80MB per segment)
-		numSegments := int(fileInfo.Size()/(80*1024*1024)) + 1
-		segmentDuration := duration / float64(numSegments)
-		fmt.Printf("Number of segments: %d\n", numSegments)
-		fmt.Printf("Segment duration: %f seconds\n", segmentDuration)
-		
-		// Split the file into segments
-		splitCmd := exec.Command("ffmpeg",
-			"-i", outputPath,
-			"-f", "segment",
-			"-segment_time", fmt.Sprintf("%f", segmentDuration),
-			"-c", "copy",
-			filepath.Join(segmentDir, "segment_%03d.mp3"))
-		
-		if err := splitCmd.Run(); err != nil {
-			os.RemoveAll(segmentDir) // Clean up on error
-			return "", fmt.Errorf("error splitting audio file: %w", err)
-		}
-		
-		// Remove the original large file
-		os.Remove(outputPath)
-		
-		// Return both the title and segment directory path
-		return title, nil
-	} else {
-		fmt.Println("Audio file is within the size limit")
-		// Move the single file to segments directory
-		singleSegmentPath := filepath.Join(segmentDir, "segment_000.mp3")
-		if err := os.Rename(outputPath, singleSegmentPath); err != nil {
-			return "", fmt.Errorf("error moving file to segments directory: %w", err)
-		}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return ytDLPError("downloading audio", err, output)
 	}
 	
-	return title, nil
+	return s.prepareSegments(outputPath, segmentDir)
 }
 
-func (s *Service) TranscribeAudio(filePath string) (string, error) {
+// TranscribeAudio transcribes the downloaded audio, or its split segments when
+// the download produced them. The segments are left in place so the same audio
+// can be translated afterwards.
+func (s *Service) TranscribeAudio(ctx context.Context, filePath string, opts TranscribeOptions) (*models.Transcript, error) {
 	segmentDir := filePath + "_segments"
 	if _, err := os.Stat(segmentDir); err == nil {
 		segments, err := filepath.Glob(filepath.Join(segmentDir, "segment_*.mp3"))
 		if err != nil {
-			return "", fmt.Errorf("error finding segments: %w", err)
+			return nil, fmt.Errorf("error finding segments: %w", err)
 		}
+		sort.Strings(segments)
 		
 		if len(segments) > 1 {
 			fmt.Printf("Processing %d segments from %s\n", len(segments), segmentDir)
 		}
-		
-		var fullTranscription strings.Builder
-		var totalDuration time.Duration
-		
-		fullTranscription.WriteString("WEBVTT\n\n")
-		
-		for i, segment := range segments {
-			transcription, err := s.transcribeSegment(segment)
-			if err != nil {
-				return "", fmt.Errorf("error transcribing segment %s: %w", segment, err)
-			}
-			
-			// Parse entries for both first and subsequent segments
-			entries, err := ParseVTT(transcription)
-			if err != nil {
-				return "", fmt.Errorf("error parsing VTT segment %d: %w", i, err)
-			}
-			
-			// Adjust timestamps and write entries
-			for _, entry := range entries {
-				if i > 0 {  // Only adjust timestamps for segments after first
-					entry.Start += totalDuration
-					entry.End += totalDuration
-				}
-				fmt.Fprintf(&fullTranscription, "%s --> %s\n%s\n\n",
-					formatTimestamp(entry.Start),
-					formatTimestamp(entry.End),
-					entry.Text)
-			}
-			
-			// Update total duration after each segment
-			if len(entries) > 0 {
-				totalDuration = entries[len(entries)-1].End
-			}
+
+		offsets, ends, err := segmentBounds(segmentDir, segments)
+		if err != nil {
+			return nil, err
 		}
-		
-		os.RemoveAll(segmentDir)
-		return fullTranscription.String(), nil
+
+		results, err := s.transcribeSegments(ctx, segments, opts)
+		if err != nil {
+			return nil, err
+		}
+		transcript := stitchSegments(results, offsets, ends)
+		if len(segments) > 1 && len(transcript.Speakers()) > 0 {
+			fmt.Printf("Warning: speakers of %s were labelled per segment and may not match across segments\n", filePath)
+		}
+		return transcript, nil
 	}
 	
 	// Handle single segment the same way as multiple segments
-	transcription, err := s.transcribeSegment(filePath)
+	var transcript *models.Transcript
+	err := retry(ctx, "Transcribing "+filePath, s.retry.Transcribe, func() error {
+		var err error
+		transcript, err = s.transcriber.Transcribe(ctx, filePath, opts)
+		return err
+	})
 	if err != nil {
-		return "", err
-	}
-
-	if strings.Contains(transcription, "\\n") {
-		transcription = strings.ReplaceAll(transcription, "\\n", "\n")
+		return nil, err
 	}
 		
-	return transcription, nil
-}
-
-func (s *Service) transcribeSegment(filePath string) (string, error) {
-	fmt.Println("Transcribing segment:", filePath)
-	fileData, err := os.ReadFile(filePath)
-	if err != nil {
-		return "", fmt.Errorf("error reading file: %w", err)
-	}
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	part, err := writer.CreateFormFile("file", filepath.Base(filePath))
-	if err != nil {
-		return "", fmt.Errorf("error creating form file: %w", err)
-	}
-	if _, err := io.Copy(part, bytes.NewReader(fileData)); err != nil {
-		return "", fmt.Errorf("error copying file data: %w", err)
-	}
-
-	writer.WriteField("language", "english")
-	writer.WriteField("response_format", "vtt")
-	writer.Close()
-
-	req, err := http.NewRequest("POST", "https://api.lemonfox.ai/v1/audio/transcriptions", body)
-	if err != nil {
-		return "", fmt.Errorf("error creating request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("error sending request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("error reading response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, respBody)
-	}
-
-	return string(respBody), nil
+	return transcript, nil
 }
 
+// ListenForNewVideos runs the worker pool over the "TranscriptionJob" queue.
+// Notifications on the new_video channel only wake idle workers early; the
+// queue itself is the source of truth, so videos inserted while the worker was
+// down are picked up by the sweep on startup and on every poll.
 func (s *Service) ListenForNewVideos() error {
 	listener := pq.NewListener(s.dbURL, 10*time.Second, time.Minute,
 		func(ev pq.ListenerEventType, err error) {
@@ -254,62 +150,56 @@ func (s *Service) ListenForNewVideos() error {
 		return fmt.Errorf("listen error: %w", err)
 	}
 
-	fmt.Println("Listening for new videos...")
+	fmt.Printf("Worker %s listening for new videos with %d workers...\n", s.workerID, s.workers)
+
+	ctx := context.Background()
+	s.sweep(ctx)
+	go s.runWorkers(ctx)
 
 	for {
 		select {
 		case n := <-listener.Notify:
 			if n == nil {
-				fmt.Println("Received nil notification")
-				continue
-			}
-			fmt.Printf("Received notification: %+v\n", n)
-			var video models.Video
-			if err := json.Unmarshal([]byte(n.Extra), &video); err != nil {
-				fmt.Printf("Error unmarshaling notification: %v\n", err)
-				continue
-			}
-			fmt.Printf("Received notification for video: %s\n", video.ID)
-			// Process video...
-			// Process the new video notification
-			if err := s.processVideoNotification(n.Extra); err != nil {
-				fmt.Printf("Error processing video: %v\n", err)
+				// The listener reconnected and may have missed notifications
+				fmt.Println("Listener reconnected, sweeping for missed videos")
+				s.sweep(ctx)
 			} else {
-				fmt.Println("Successfully processed video notification")
+				fmt.Printf("Received notification for video: %s\n", n.Extra)
 			}
-		case <-time.After(time.Minute):
+			s.wakeWorkers()
+		case <-time.After(pollInterval):
 			fmt.Println("Ping check...")
 			go func() {
 				if err := listener.Ping(); err != nil {
 					fmt.Printf("Ping error: %v\n", err)
 				}
 			}()
+			s.sweep(ctx)
 		}
 	}
 }
 
-func (s *Service) processVideoNotification(notification string) error {
-	var video models.Video
-	if err := json.Unmarshal([]byte(notification), &video); err != nil {
-		return fmt.Errorf("json parse error: %w", err)
-	}
-
+func (s *Service) processVideo(ctx context.Context, video *models.Video) error {
 	fmt.Printf("Processing video ID: %s, URL: %s\n", video.ID, video.VideoURL)
-	var transcription string
+	var transcript *models.Transcript
 
 	// Check for existing transcription
 	existingVideo, err := s.transcriptionRepo.GetByURL(video.VideoURL)
-	if err == nil && existingVideo.Transcription != nil {
+	if err == nil && existingVideo.Transcription != nil && canReuse(existingVideo, video) {
 		fmt.Printf("Found existing transcription for video URL: %s\n", video.VideoURL)
-		transcription = *existingVideo.Transcription
+		transcript = existingVideo.Transcript
+		if transcript == nil {
+			// Transcribed before transcripts were stored, so parse the VTT
+			entries, err := ParseVTT(*existingVideo.Transcription)
+			if err != nil {
+				return Permanent(fmt.Errorf("failed to parse VTT: %w", err))
+			}
+			transcript = models.TranscriptFromEntries(entries)
+		}
 	} else {
 		// If no existing transcription, proceed with download and transcribe
 		fmt.Printf("No existing transcription found, processing video ID: %s, URL: %s\n", video.ID, video.VideoURL)
 		
-		if err := s.transcriptionRepo.UpdateVideoStatus(video.ID, "processing"); err != nil {
-			return fmt.Errorf("failed to update status to processing: %w", err)
-		}
-		
 		// Create temp directory in current directory for local development
 		tempDir := "temp"
 		if os.Getenv("RAILWAY_ENVIRONMENT") != "" {
@@ -320,39 +210,58 @@ func (s *Service) processVideoNotification(notification string) error {
 			return fmt.Errorf("failed to create temp directory: %w", err)
 		}
 		
-		outputPath := filepath.Join(tempDir, fmt.Sprintf("temp_%s.mp3", video.ID))
-		defer os.Remove(outputPath)
-
-		fmt.Printf("Downloading audio to: %s\n", outputPath)
-		title, err := s.DownloadAudio(video.VideoURL, outputPath)
-		if err != nil {
-			s.transcriptionRepo.UpdateVideoStatus(video.ID, "failed")
-			return fmt.Errorf("download error: %w", err)
-		}
-		fmt.Printf("Downloaded audio title: %s\n", title)
-		// Save the video title
-		if err := s.transcriptionRepo.UpdateVideoTitle(video.ID, title); err != nil {
-			fmt.Printf("Warning: failed to save video title: %v\n", err)
-			// Don't return error here as it's not critical to the main flow
+		// Feed episodes and uploads get their title when they are added, and
+		// yt-dlp knows nothing about them
+		if video.SourceType == models.SourceYouTube {
+			if err := s.downloadLimit.acquire(ctx); err != nil {
+				return err
+			}
+			var metadata *models.VideoMetadata
+			err := retry(ctx, "Fetching metadata of "+video.VideoURL, s.retry.Download, func() error {
+				var err error
+				metadata, err = s.FetchMetadata(video.VideoURL)
+				return err
+			})
+			s.downloadLimit.release()
+			if err != nil {
+				return fmt.Errorf("download error: %w", err)
+			}
+			fmt.Printf("Video title: %s\n", metadata.Title)
+			// Save the video metadata
+			if err := s.transcriptionRepo.UpdateVideoMetadata(video.ID, metadata); err != nil {
+				fmt.Printf("Warning: failed to save video metadata: %v\n", err)
+				// Don't return error here as it's not critical to the main flow
+			}
 		}
-		fmt.Println("Audio download completed successfully")
 
-		fmt.Println("Sending audio to Lemonfox for transcription...")
-		
-		transcription, err = s.TranscribeAudio(outputPath)
+		var source string
+		var translation *models.Transcript
+		transcript, translation, source, err = s.transcribeVideo(ctx, video, tempDir)
 		if err != nil {
-			s.transcriptionRepo.UpdateVideoStatus(video.ID, "failed")
-			return fmt.Errorf("transcription error: %w", err)
+			return err
 		}
-		fmt.Println("Transcription received", transcription)
+		fmt.Printf("Transcription received from %s\n", source)
 
 		// Save full transcription first
-		if err := s.transcriptionRepo.SaveFullTranscription(video.ID, transcription); err != nil {
+		transcription := writeVTT(transcript.Entries())
+		err = retry(ctx, "Saving transcription", s.retry.Save, func() error {
+			return s.transcriptionRepo.SaveFullTranscription(video.ID, transcription, transcript, source)
+		})
+		if err != nil {
 			return fmt.Errorf("failed to save transcription: %w", err)
 		}
+
+		if translation != nil {
+			err = retry(ctx, "Saving translation", s.retry.Save, func() error {
+				return s.transcriptionRepo.SaveTranslation(video.ID, translation)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to save translation: %w", err)
+			}
+		}
 	}
 
-	if transcription == "" {
+	if transcript == nil {
 		return fmt.Errorf("no transcription found: neither existing nor newly generated transcription was successful")
 	}
 
@@ -360,100 +269,187 @@ func (s *Service) processVideoNotification(notification string) error {
 	if video.IsSearchable {
 		fmt.Println("isSearchable: Processing video ID:", video.ID)
 		
-		// 1. Parse VTT content
-		vttEntries, err := ParseVTT(transcription)
-		if err != nil {
-			return fmt.Errorf("failed to parse VTT: %w", err)
+		// 1. Flatten the transcript into timed cues
+		entries := transcript.Entries()
+		fmt.Println("Transcript segments:", len(entries))
+		// 2. Group cues into search chunks that keep their real timestamps
+		chunks := chunkEntries(entries, 30*time.Second, 5*time.Second)
+		attachWords(chunks, transcript)
+
+		// 3. Generate an embedding for each chunk
+		if err := s.embedLimit.acquire(ctx); err != nil {
+			return err
 		}
-		fmt.Println("VTT entries:", vttEntries)
-		// 2. Convert VTT to plain text for search
-		plainText := ""
-		for _, entry := range vttEntries {
-			plainText += entry.Text + " "
-		}
-		plainText = strings.TrimSpace(plainText)
-		fmt.Println("Plain text:", plainText)
-		// 3. Create semantic search chunks from plain text
-		chunks, err := s.chunkText(plainText, 30*time.Second, 5*time.Second)
+		err = embedChunks(ctx, s.embedder, s.retry.Embed, chunks)
+		s.embedLimit.release()
 		if err != nil {
 			return fmt.Errorf("failed to create chunks: %w", err)
 		}
 		
 		// 4. Save chunks with embeddings
-		if err := s.transcriptionRepo.SaveChunks(video.ID, chunks); err != nil {
+		err = retry(ctx, "Saving chunks", s.retry.Save, func() error {
+			return s.transcriptionRepo.SaveChunks(video.ID, s.embedder.Model(), chunks)
+		})
+		if err != nil {
 			return fmt.Errorf("failed to save chunks: %w", err)
 		}
 	}
 
-	return s.transcriptionRepo.UpdateVideoStatus(video.ID, "completed")
+	return s.transcriptionRepo.MarkVideoCompleted(video.ID)
+}
+
+// transcribeVideo reuses the video's YouTube captions when the caption policy
+// allows it, and otherwise downloads the audio and sends it for transcription.
+// When the video asks for a translation the audio is always transcribed, and
+// translated too unless it is already in English.
+// Feed episodes are downloaded straight from their enclosure URL and uploads
+// are read from the upload directory.
+// It returns the transcript with the source it came from.
+func (s *Service) transcribeVideo(ctx context.Context, video *models.Video, tempDir string) (*models.Transcript, *models.Transcript, string, error) {
+	var opts TranscribeOptions
+	if video.RequestedLanguage != nil {
+		opts.Language = *video.RequestedLanguage
+	}
+
+	if s.captionPolicy != CaptionsNever && video.SourceType == models.SourceYouTube && !video.Translate {
+		if err := s.downloadLimit.acquire(ctx); err != nil {
+			return nil, nil, "", err
+		}
+		var captions, source, language string
+		err := retry(ctx, "Fetching captions for "+video.VideoURL, s.retry.Download, func() error {
+			var err error
+			captions, source, language, err = s.FetchCaptions(video.VideoURL, filepath.Join(tempDir, "captions_"+video.ID), opts.Language)
+			return err
+		})
+		s.downloadLimit.release()
+		if err != nil {
+			// Captions only save money, so fall back to transcription
+			fmt.Printf("Warning: failed to fetch captions, transcribing instead: %v\n", err)
+		} else if captions != "" {
+			// captionsToVTT already checked the captions parse
+			entries, err := ParseVTT(captions)
+			if err == nil {
+				transcript := models.TranscriptFromEntries(entries)
+				transcript.Language = language
+				return transcript, nil, source, nil
+			}
+			fmt.Printf("Warning: failed to parse captions, transcribing instead: %v\n", err)
+		}
+		fmt.Println("No usable captions found, transcribing audio")
+	}
+
+	outputPath := filepath.Join(tempDir, fmt.Sprintf("temp_%s.mp3", video.ID))
+	defer os.Remove(outputPath)
+	defer os.RemoveAll(outputPath + "_segments")
+
+	fmt.Printf("Downloading audio to: %s\n", outputPath)
+	if err := s.downloadLimit.acquire(ctx); err != nil {
+		return nil, nil, "", err
+	}
+	err := retry(ctx, "Downloading "+video.VideoURL, s.retry.Download, func() error {
+		switch video.SourceType {
+		case models.SourceRSS:
+			return s.DownloadDirect(ctx, video.VideoURL, outputPath)
+		case models.SourceUpload:
+			return s.PrepareUpload(video.VideoURL, outputPath)
+		default:
+			return s.DownloadAudio(video.VideoURL, outputPath)
+		}
+	})
+	s.downloadLimit.release()
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("download error: %w", err)
+	}
+	fmt.Println("Audio download completed successfully")
+
+	fmt.Println("Sending audio for transcription...")
+	if err := s.transcribeLimit.acquire(ctx); err != nil {
+		return nil, nil, "", err
+	}
+	defer s.transcribeLimit.release()
+
+	transcript, err := s.TranscribeAudio(ctx, outputPath, opts)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("transcription error: %w", err)
+	}
+	if transcript.Language != "" {
+		fmt.Printf("Transcribed language: %s\n", transcript.Language)
+	}
+	// Formats that don't report the language were asked for the requested one
+	language := transcript.Language
+	if language == "" {
+		language = opts.Language
+	}
+	if !video.Translate || isEnglish(language) {
+		return transcript, nil, SourceTranscription, nil
+	}
+
+	fmt.Println("Sending audio for translation...")
+	opts.Translate = true
+	translation, err := s.TranscribeAudio(ctx, outputPath, opts)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("translation error: %w", err)
+	}
+	return transcript, translation, SourceTranscription, nil
+}
+
+// canReuse reports whether the transcript stored for existing can stand in
+// for transcribing video. Translations are never stored with a reused
+// transcript, and a requested language must be the one the existing
+// transcript was requested or detected in.
+func canReuse(existing *models.Video, video *models.Video) bool {
+	if video.Translate {
+		return false
+	}
+	if video.RequestedLanguage == nil || *video.RequestedLanguage == "" {
+		return true
+	}
+	requested := *video.RequestedLanguage
+	if existing.RequestedLanguage != nil && sameLanguage(*existing.RequestedLanguage, requested) {
+		return true
+	}
+	return existing.DetectedLanguage != nil && sameLanguage(*existing.DetectedLanguage, requested)
 }
 
-func (s *Service) chunkText(plainText string, maxChunkDuration time.Duration, overlap time.Duration) ([]models.Chunk, error) {
-    
-	fmt.Println("Chunking text:", plainText)
-	fmt.Println("Max chunk duration:", maxChunkDuration)
-	fmt.Println("Overlap:", overlap)
-	// Split text into sentences or paragraphs
-    sentences := strings.Split(plainText, ". ")
-    
-    var chunks []models.Chunk
-    var currentChunk strings.Builder
-    chunkStartIndex := 0
-    
-    for i, sentence := range sentences {
-        currentChunk.WriteString(sentence)
-        currentChunk.WriteString(". ")
-        
-        // Create new chunk if we've accumulated enough text or it's the last sentence
-        if i == len(sentences)-1 || currentChunk.Len() > 500 { // arbitrary length of ~500 chars per chunk
-            text := strings.TrimSpace(currentChunk.String())
-            
-            // Generate embedding for the chunk
-            embedding, err := embeddings.GetEmbedding(text, os.Getenv("OPENAI_API_KEY"))
-            if err != nil {
-                return nil, fmt.Errorf("failed to generate embedding: %w", err)
-            }
-            
-            chunks = append(chunks, models.Chunk{
-                Text:      text,
-                StartTime: time.Duration(chunkStartIndex) * maxChunkDuration,
-                EndTime:   time.Duration(i+1) * maxChunkDuration,
-                Embedding: embedding,
-            })
-            
-            // Start new chunk with overlap
-            if i < len(sentences)-1 {
-                currentChunk.Reset()
-                // Add some overlap by including the last few sentences
-                overlapStart := max(0, i-2) // overlap of ~2 sentences
-                for j := overlapStart; j <= i; j++ {
-                    currentChunk.WriteString(sentences[j])
-                    currentChunk.WriteString(". ")
-                }
-                chunkStartIndex = overlapStart
-            }
-        }
-    }
-    
-    return chunks, nil
+// sameLanguage compares language codes without their region, since caption
+// tracks are often labelled with one, as in de-DE
+func sameLanguage(a string, b string) bool {
+	a, _, _ = strings.Cut(a, "-")
+	b, _, _ = strings.Cut(b, "-")
+	return strings.EqualFold(a, b)
 }
 
-func max(a, b int) int {
-    if a > b {
-        return a
-    }
-    return b
+// isEnglish reports whether a language code or name, as providers report
+// them, is English
+func isEnglish(language string) bool {
+	language = strings.ToLower(language)
+	return language == "en" || language == "english" || strings.HasPrefix(language, "en-")
 }
 
-// Helper function to format Duration as VTT timestamp
-func formatTimestamp(d time.Duration) string {
-    h := d / time.Hour
-    d -= h * time.Hour
-    m := d / time.Minute
-    d -= m * time.Minute
-    s := d / time.Second
-    d -= s * time.Second
-    ms := d / time.Millisecond
-    
-    return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ms)
+// embedChunks embeds every chunk of a video; the embedder batches the texts
+// into as few requests as it can
+func embedChunks(ctx context.Context, embedder embeddings.Embedder, policy RetryPolicy, chunks []models.Chunk) error {
+	if embedder == nil {
+		return Permanent(fmt.Errorf("no embedding provider configured: set EMBEDDING_PROVIDER or OPENAI_API_KEY"))
+	}
+
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Text
+	}
+
+	var vectors [][]float32
+	err := retry(ctx, fmt.Sprintf("Embedding %d chunks", len(chunks)), policy, func() error {
+		var err error
+		vectors, err = embedder.Embed(ctx, texts)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+
+	for i := range chunks {
+		chunks[i].Embedding = vectors[i]
+	}
+	return nil
 }
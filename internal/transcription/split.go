@@ -0,0 +1,229 @@
+package transcription
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+const (
+	// maxUploadSize is the largest file sent to the provider in one request
+	maxUploadSize = 90 * 1024 * 1024 // 90MB in bytes
+	// targetSegmentSize leaves headroom under maxUploadSize for cuts that
+	// move to a nearby silence and for the overlap
+	targetSegmentSize = 80 * 1024 * 1024
+
+	// silenceNoise and silenceMinDuration tune ffmpeg's silencedetect filter
+	silenceNoise       = "-30dB"
+	silenceMinDuration = 0.5
+
+	segmentManifest = "segments.json"
+)
+
+// silence is a quiet stretch of audio reported by ffmpeg's silencedetect
+type silence struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// prepareSegments moves the audio at outputPath into segmentDir, splitting it
+// at silences when it is too large to upload in one request
+func (s *Service) prepareSegments(outputPath string, segmentDir string) error {
+	// Check file size
+	fileInfo, err := os.Stat(outputPath)
+	if err != nil {
+		return fmt.Errorf("error checking file size: %w", err)
+	}
+
+	fmt.Printf("File size: %d bytes\n", fileInfo.Size())
+	if fileInfo.Size() <= maxUploadSize {
+		fmt.Println("Audio file is within the size limit")
+		// Move the single file to segments directory
+		singleSegmentPath := filepath.Join(segmentDir, "segment_000.mp3")
+		if err := os.Rename(outputPath, singleSegmentPath); err != nil {
+			return fmt.Errorf("error moving file to segments directory: %w", err)
+		}
+		return nil
+	}
+
+	fmt.Println("Audio file too large, splitting...")
+	duration, err := probeDuration(outputPath)
+	if err != nil {
+		return err
+	}
+
+	numSegments := int(fileInfo.Size()/targetSegmentSize) + 1
+	segmentDuration := duration / time.Duration(numSegments)
+	fmt.Printf("Number of segments: %d\n", numSegments)
+	fmt.Printf("Segment duration: %v\n", segmentDuration)
+
+	silences, err := detectSilences(outputPath)
+	if err != nil {
+		// Splitting at fixed lengths still works, it just cuts mid-word
+		fmt.Printf("Warning: silence detection failed, splitting at fixed lengths: %v\n", err)
+	}
+
+	cuts := planCuts(duration, numSegments, silences)
+	if err := splitAudio(outputPath, segmentDir, cuts, duration, s.segmentOverlap); err != nil {
+		os.RemoveAll(segmentDir) // Clean up on error
+		return err
+	}
+
+	// Remove the original large file
+	os.Remove(outputPath)
+	return nil
+}
+
+// detectSilences runs ffmpeg's silencedetect filter over the file
+func detectSilences(path string) ([]silence, error) {
+	cmd := exec.Command("ffmpeg",
+		"-hide_banner", "-nostats",
+		"-i", path,
+		"-af", fmt.Sprintf("silencedetect=noise=%s:d=%g", silenceNoise, silenceMinDuration),
+		"-f", "null", "-")
+
+	// silencedetect reports on stderr
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("error detecting silence: %w", err)
+	}
+	return parseSilenceDetect(string(output)), nil
+}
+
+var (
+	silenceStartPattern = regexp.MustCompile(`silence_start: (-?[0-9.]+)`)
+	silenceEndPattern   = regexp.MustCompile(`silence_end: ([0-9.]+)`)
+)
+
+// parseSilenceDetect extracts the silent ranges from silencedetect output
+func parseSilenceDetect(output string) []silence {
+	var silences []silence
+	starts := silenceStartPattern.FindAllStringSubmatchIndex(output, -1)
+	ends := silenceEndPattern.FindAllStringSubmatchIndex(output, -1)
+
+	for _, start := range starts {
+		startSeconds, err := strconv.ParseFloat(output[start[2]:start[3]], 64)
+		if err != nil {
+			continue
+		}
+		// Pair each start with the first end reported after it
+		for _, end := range ends {
+			if end[0] < start[0] {
+				continue
+			}
+			endSeconds, err := strconv.ParseFloat(output[end[2]:end[3]], 64)
+			if err != nil {
+				break
+			}
+			silences = append(silences, silence{
+				Start: models.SecondsToDuration(max(startSeconds, 0)),
+				End:   models.SecondsToDuration(endSeconds),
+			})
+			break
+		}
+	}
+	return silences
+}
+
+// planCuts picks numSegments-1 cut points, moving each evenly spaced cut to
+// the middle of the nearest silence within a tenth of a segment
+func planCuts(duration time.Duration, numSegments int, silences []silence) []time.Duration {
+	segmentDuration := duration / time.Duration(numSegments)
+	window := segmentDuration / 10
+
+	var cuts []time.Duration
+	previous := time.Duration(0)
+	for k := 1; k < numSegments; k++ {
+		ideal := time.Duration(k) * segmentDuration
+		cut := ideal
+
+		bestDistance := window + 1
+		for _, quiet := range silences {
+			mid := quiet.Start + (quiet.End-quiet.Start)/2
+			distance := mid - ideal
+			if distance < 0 {
+				distance = -distance
+			}
+			if distance <= window && distance < bestDistance && mid > previous {
+				cut = mid
+				bestDistance = distance
+			}
+		}
+
+		cuts = append(cuts, cut)
+		previous = cut
+	}
+	return cuts
+}
+
+// splitAudio writes one segment per cut range, extending every segment but the
+// last by overlap, and records each segment's start in the manifest
+func splitAudio(path string, segmentDir string, cuts []time.Duration, duration time.Duration, overlap time.Duration) error {
+	bounds := append([]time.Duration{0}, cuts...)
+	bounds = append(bounds, duration)
+
+	offsets := make([]float64, 0, len(bounds)-1)
+	for i := 0; i < len(bounds)-1; i++ {
+		start := bounds[i]
+		end := bounds[i+1]
+		if i < len(bounds)-2 {
+			end = min(end+overlap, duration)
+		}
+
+		segmentPath := filepath.Join(segmentDir, fmt.Sprintf("segment_%03d.mp3", i))
+		splitCmd := exec.Command("ffmpeg",
+			"-y",
+			"-ss", fmt.Sprintf("%f", start.Seconds()),
+			"-i", path,
+			"-t", fmt.Sprintf("%f", (end-start).Seconds()),
+			"-c", "copy",
+			segmentPath)
+
+		if output, err := splitCmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("error splitting audio file: %w, output: %s", err, output)
+		}
+		offsets = append(offsets, start.Seconds())
+	}
+
+	manifest, err := json.Marshal(offsets)
+	if err != nil {
+		return fmt.Errorf("error encoding segment manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(segmentDir, segmentManifest), manifest, 0644); err != nil {
+		return fmt.Errorf("error writing segment manifest: %w", err)
+	}
+	return nil
+}
+
+// readSegmentOffsets returns the offsets recorded by splitAudio, or nil when
+// the segments were not split by it
+func readSegmentOffsets(segmentDir string, count int) ([]time.Duration, error) {
+	data, err := os.ReadFile(filepath.Join(segmentDir, segmentManifest))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading segment manifest: %w", err)
+	}
+
+	var seconds []float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return nil, fmt.Errorf("error parsing segment manifest: %w", err)
+	}
+	if len(seconds) != count {
+		return nil, fmt.Errorf("segment manifest lists %d segments, found %d", len(seconds), count)
+	}
+
+	offsets := make([]time.Duration, len(seconds))
+	for i, s := range seconds {
+		offsets[i] = models.SecondsToDuration(s)
+	}
+	return offsets, nil
+}
@@ -0,0 +1,53 @@
+package transcription
+
+import (
+	"testing"
+	"time"
+)
+
+const silenceDetectOutput = `Input #0, mp3, from 'temp_abc.mp3':
+  Duration: 00:10:00.05, start: 0.025057, bitrate: 320 kb/s
+[silencedetect @ 0x5581] silence_start: -0.0123
+[silencedetect @ 0x5581] silence_end: 0.8 | silence_duration: 0.81
+[silencedetect @ 0x5581] silence_start: 295.5
+[silencedetect @ 0x5581] silence_end: 296.5 | silence_duration: 1
+[silencedetect @ 0x5581] silence_start: 410
+[silencedetect @ 0x5581] silence_end: 411.2 | silence_duration: 1.2
+`
+
+func TestParseSilenceDetect(t *testing.T) {
+	got := parseSilenceDetect(silenceDetectOutput)
+	want := []silence{
+		{Start: 0, End: 800 * time.Millisecond},
+		{Start: 295500 * time.Millisecond, End: 296500 * time.Millisecond},
+		{Start: 410 * time.Second, End: 411200 * time.Millisecond},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("parseSilenceDetect() got %d silences, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("silence %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestPlanCuts(t *testing.T) {
+	silences := parseSilenceDetect(silenceDetectOutput)
+
+	// 10 minutes in two segments: the even cut at 300s moves to the silence
+	// centred on 296s, which is within a tenth of a segment (30s)
+	got := planCuts(10*time.Minute, 2, silences)
+	if len(got) != 1 || got[0] != 296*time.Second {
+		t.Errorf("planCuts() = %v, want [296s]", got)
+	}
+
+	// Three segments: the cut at 200s has no silence nearby and stays put,
+	// the cut at 400s moves to the silence centred on 410.6s
+	got = planCuts(10*time.Minute, 3, silences)
+	want := []time.Duration{200 * time.Second, 410600 * time.Millisecond}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("planCuts() = %v, want %v", got, want)
+	}
+}
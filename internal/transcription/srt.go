@@ -0,0 +1,60 @@
+package transcription
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+// ParseSRT parses SubRip subtitles. Cue numbers are optional, CRLF line
+// endings are accepted, and timestamps may use a comma or a dot before the
+// milliseconds.
+func ParseSRT(content string) ([]models.SRTEntry, error) {
+	content = strings.TrimPrefix(content, "\ufeff")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	entries := []models.SRTEntry{}
+	for _, block := range strings.Split(content, "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+		if len(lines) == 1 && strings.TrimSpace(lines[0]) == "" {
+			continue
+		}
+
+		if _, err := strconv.Atoi(strings.TrimSpace(lines[0])); err == nil && len(lines) > 1 {
+			lines = lines[1:]
+		}
+
+		start, end, found := strings.Cut(lines[0], "-->")
+		if !found {
+			return nil, fmt.Errorf("invalid SRT cue: missing timing line in %q", block)
+		}
+		startTime, err := parseSRTTimestamp(start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start timestamp: %w", err)
+		}
+		// Some tools append VTT-style position settings after the end time
+		endFields := strings.Fields(end)
+		if len(endFields) == 0 {
+			return nil, fmt.Errorf("invalid SRT cue: missing end timestamp in %q", lines[0])
+		}
+		endTime, err := parseSRTTimestamp(endFields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid end timestamp: %w", err)
+		}
+
+		entries = append(entries, models.SRTEntry{
+			Number: len(entries) + 1,
+			Start:  startTime,
+			End:    endTime,
+			Text:   strings.Join(lines[1:], " "),
+		})
+	}
+	return entries, nil
+}
+
+func parseSRTTimestamp(timestamp string) (time.Duration, error) {
+	return parseVTTTimestamp(strings.Replace(strings.TrimSpace(timestamp), ",", ".", 1))
+}
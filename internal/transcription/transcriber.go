@@ -0,0 +1,268 @@
+package transcription
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"jamesfarrell.me/youtube-to-text/internal/config"
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+const (
+	lemonfoxBaseURL = "https://api.lemonfox.ai/v1"
+	openAIBaseURL   = "https://api.openai.com/v1"
+
+	defaultOpenAIModel = "whisper-1"
+)
+
+// ResponseOptions choose what the provider is asked to return
+type ResponseOptions struct {
+	// Format is "verbose_json" (the default), "srt" or "vtt"
+	Format string
+	// WordTimestamps asks for word timings as well as segments; only
+	// verbose_json can carry them
+	WordTimestamps bool
+	// SpeakerLabels asks for diarization, labelling each segment with its
+	// speaker; needs verbose_json
+	SpeakerLabels bool
+}
+
+// TranscribeOptions are the per-video settings of a transcription request
+type TranscribeOptions struct {
+	// Language is the spoken language as an ISO-639-1 code such as "de".
+	// When empty the provider detects it.
+	Language string
+	// Translate asks for an English translation instead of a transcript in
+	// the spoken language
+	Translate bool
+}
+
+// Transcriber turns an audio file into a transcript
+type Transcriber interface {
+	Transcribe(ctx context.Context, filePath string, opts TranscribeOptions) (*models.Transcript, error)
+}
+
+// NewTranscriber builds the Transcriber selected by the config
+func NewTranscriber(cfg config.TranscriberConfig) (Transcriber, error) {
+	switch cfg.ResponseFormat {
+	case "", ResponseVerboseJSON, ResponseSRT, ResponseVTT:
+	default:
+		return nil, fmt.Errorf("unsupported transcription response format %q: use verbose_json, srt or vtt", cfg.ResponseFormat)
+	}
+	opts := ResponseOptions{
+		Format:         cfg.ResponseFormat,
+		WordTimestamps: cfg.WordTimestamps,
+		SpeakerLabels:  cfg.SpeakerLabels,
+	}
+	if opts.SpeakerLabels {
+		if opts.withDefaults().Format != ResponseVerboseJSON {
+			return nil, fmt.Errorf("speaker labels need the verbose_json response format")
+		}
+		if cfg.Provider == "openai" {
+			return nil, fmt.Errorf("the openai transcriber does not support speaker labels")
+		}
+	}
+
+	switch cfg.Provider {
+	case "lemonfox":
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("lemonfox transcriber requires an API key")
+		}
+		return NewLemonfoxTranscriber(cfg.APIKey, opts), nil
+	case "openai":
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("openai transcriber requires an API key")
+		}
+		return NewOpenAITranscriber(cfg.APIKey, cfg.Model, opts), nil
+	case "openai-compatible":
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("openai-compatible transcriber requires a base URL")
+		}
+		return NewOpenAICompatibleTranscriber(cfg.BaseURL, cfg.APIKey, cfg.Model, opts), nil
+	default:
+		return nil, fmt.Errorf("unknown transcription provider: %q", cfg.Provider)
+	}
+}
+
+// LemonfoxTranscriber uses the Lemonfox Whisper API
+type LemonfoxTranscriber struct {
+	apiKey     string
+	response   ResponseOptions
+	httpClient *http.Client
+}
+
+func NewLemonfoxTranscriber(apiKey string, response ResponseOptions) *LemonfoxTranscriber {
+	return &LemonfoxTranscriber{
+		apiKey:     apiKey,
+		response:   response.withDefaults(),
+		httpClient: &http.Client{},
+	}
+}
+
+// Transcribe translates through the transcriptions endpoint, which Lemonfox
+// switches to English output with translate=true
+func (t *LemonfoxTranscriber) Transcribe(ctx context.Context, filePath string, opts TranscribeOptions) (*models.Transcript, error) {
+	fields := t.response.fields()
+	if opts.Language != "" {
+		fields.Set("language", opts.Language)
+	}
+	if opts.Translate {
+		fields.Set("translate", "true")
+	}
+
+	body, err := postAudio(ctx, t.httpClient, lemonfoxBaseURL+"/audio/transcriptions", t.apiKey, filePath, fields)
+	if err != nil {
+		return nil, err
+	}
+	return parseResult(body, t.response.Format, opts)
+}
+
+// OpenAICompatibleTranscriber talks to any server implementing the OpenAI
+// /audio/transcriptions and /audio/translations endpoints, including OpenAI
+// itself and local whisper servers
+type OpenAICompatibleTranscriber struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	response   ResponseOptions
+	httpClient *http.Client
+}
+
+// NewOpenAITranscriber uses the OpenAI Whisper API, defaulting to whisper-1
+func NewOpenAITranscriber(apiKey string, model string, response ResponseOptions) *OpenAICompatibleTranscriber {
+	return NewOpenAICompatibleTranscriber(openAIBaseURL, apiKey, model, response)
+}
+
+func NewOpenAICompatibleTranscriber(baseURL string, apiKey string, model string, response ResponseOptions) *OpenAICompatibleTranscriber {
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	return &OpenAICompatibleTranscriber{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		response:   response.withDefaults(),
+		httpClient: &http.Client{},
+	}
+}
+
+// Transcribe uses /audio/translations to translate. That endpoint always
+// translates into English and takes neither a language nor word timestamps.
+func (t *OpenAICompatibleTranscriber) Transcribe(ctx context.Context, filePath string, opts TranscribeOptions) (*models.Transcript, error) {
+	endpoint := "/audio/transcriptions"
+	fields := t.response.fields()
+	if opts.Translate {
+		endpoint = "/audio/translations"
+		fields = url.Values{"response_format": {t.response.Format}}
+	} else if opts.Language != "" {
+		fields.Set("language", opts.Language)
+	}
+	fields.Set("model", t.model)
+
+	body, err := postAudio(ctx, t.httpClient, t.baseURL+endpoint, t.apiKey, filePath, fields)
+	if err != nil {
+		return nil, err
+	}
+	return parseResult(body, t.response.Format, opts)
+}
+
+// parseResult parses the response and sets the language of translations to
+// English. Transcripts in formats that don't report a language are left
+// without one rather than assumed to be in the requested language.
+func parseResult(body string, format string, opts TranscribeOptions) (*models.Transcript, error) {
+	transcript, err := parseTranscript(body, format)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Translate {
+		transcript.Language = "en"
+	}
+	return transcript, nil
+}
+
+// withDefaults asks for verbose_json unless told otherwise, since it carries
+// segment confidence and word timings
+func (o ResponseOptions) withDefaults() ResponseOptions {
+	if o.Format == "" {
+		o.Format = ResponseVerboseJSON
+	}
+	return o
+}
+
+// fields are the form fields that request the response format. Asking for
+// word timestamps must also name segments, or OpenAI returns words only.
+func (o ResponseOptions) fields() url.Values {
+	fields := url.Values{"response_format": {o.Format}}
+	if o.WordTimestamps && o.Format == ResponseVerboseJSON {
+		fields["timestamp_granularities[]"] = []string{"segment", "word"}
+	}
+	if o.SpeakerLabels && o.Format == ResponseVerboseJSON {
+		fields.Set("speaker_labels", "true")
+	}
+	return fields
+}
+
+// postAudio uploads the file as multipart form data along with the given fields
+// and returns the raw response body
+func postAudio(ctx context.Context, client *http.Client, url string, apiKey string, filePath string, fields url.Values) (string, error) {
+	fmt.Println("Transcribing segment:", filePath)
+	fileData, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("error reading file: %w", err)
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile("file", filepath.Base(filePath))
+	if err != nil {
+		return "", fmt.Errorf("error creating form file: %w", err)
+	}
+	if _, err := io.Copy(part, bytes.NewReader(fileData)); err != nil {
+		return "", fmt.Errorf("error copying file data: %w", err)
+	}
+
+	for name, values := range fields {
+		for _, value := range values {
+			if err := writer.WriteField(name, value); err != nil {
+				return "", fmt.Errorf("error writing form field %s: %w", name, err)
+			}
+		}
+	}
+	writer.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &StatusError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	return string(respBody), nil
+}
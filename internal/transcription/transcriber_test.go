@@ -0,0 +1,244 @@
+package transcription
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"jamesfarrell.me/youtube-to-text/internal/config"
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+const sampleVTT = "WEBVTT\n\n00:00:00.000 --> 00:00:02.000\nHello there\n"
+
+func writeTempAudio(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "segment_000.mp3")
+	if err := os.WriteFile(path, []byte("fake audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpenAICompatibleTranscriber(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if got := r.FormValue("model"); got != "whisper-large-v3" {
+			t.Errorf("model = %q", got)
+		}
+		if got := r.FormValue("response_format"); got != "vtt" {
+			t.Errorf("response_format = %q", got)
+		}
+		if got := r.FormValue("timestamp_granularities[]"); got != "" {
+			t.Errorf("timestamp_granularities[] = %q, want none for vtt", got)
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("missing file: %v", err)
+		}
+		data, _ := io.ReadAll(file)
+		if header.Filename != "segment_000.mp3" || string(data) != "fake audio" {
+			t.Errorf("unexpected file %s: %q", header.Filename, data)
+		}
+		io.WriteString(w, sampleVTT)
+	}))
+	defer server.Close()
+
+	transcriber := NewOpenAICompatibleTranscriber(server.URL+"/v1/", "test-key", "whisper-large-v3", ResponseOptions{Format: ResponseVTT, WordTimestamps: true})
+	got, err := transcriber.Transcribe(context.Background(), writeTempAudio(t), TranscribeOptions{})
+	if err != nil {
+		t.Fatalf("Transcribe() error = %v", err)
+	}
+	if len(got.Segments) != 1 || got.Segments[0].Text != "Hello there" || got.Segments[0].End != 2*time.Second {
+		t.Errorf("Transcribe() = %+v, want the VTT cue", got.Segments)
+	}
+}
+
+func TestOpenAICompatibleTranscriberVerboseJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.FormValue("response_format"); got != ResponseVerboseJSON {
+			t.Errorf("response_format = %q, want verbose_json by default", got)
+		}
+		if got := r.MultipartForm.Value["timestamp_granularities[]"]; len(got) != 2 || got[0] != "segment" || got[1] != "word" {
+			t.Errorf("timestamp_granularities[] = %q, want segment and word", got)
+		}
+		io.WriteString(w, sampleVerboseJSON)
+	}))
+	defer server.Close()
+
+	transcriber := NewOpenAICompatibleTranscriber(server.URL, "", "", ResponseOptions{WordTimestamps: true})
+	got, err := transcriber.Transcribe(context.Background(), writeTempAudio(t), TranscribeOptions{})
+	if err != nil {
+		t.Fatalf("Transcribe() error = %v", err)
+	}
+	if got.Language != "english" || len(got.Segments) != 2 {
+		t.Fatalf("Transcribe() = %+v", got)
+	}
+	if words := got.Segments[1].Words; len(words) != 2 || words[0].Text != "General" {
+		t.Errorf("second segment words = %+v, want top-level words assigned by time", words)
+	}
+}
+
+func TestOpenAICompatibleTranscriberLanguage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/audio/transcriptions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.FormValue("language"); got != "de" {
+			t.Errorf("language = %q, want de", got)
+		}
+		io.WriteString(w, sampleVTT)
+	}))
+	defer server.Close()
+
+	transcriber := NewOpenAICompatibleTranscriber(server.URL, "", "", ResponseOptions{Format: ResponseVTT})
+	got, err := transcriber.Transcribe(context.Background(), writeTempAudio(t), TranscribeOptions{Language: "de"})
+	if err != nil {
+		t.Fatalf("Transcribe() error = %v", err)
+	}
+	if got.Language != "" {
+		t.Errorf("Language = %q, want none for formats that don't report it", got.Language)
+	}
+}
+
+func TestOpenAICompatibleTranscriberTranslate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/audio/translations" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.FormValue("language"); got != "" {
+			t.Errorf("language = %q, want none for translations", got)
+		}
+		if got := r.FormValue("timestamp_granularities[]"); got != "" {
+			t.Errorf("timestamp_granularities[] = %q, want none for translations", got)
+		}
+		if got := r.FormValue("model"); got != defaultOpenAIModel {
+			t.Errorf("model = %q", got)
+		}
+		io.WriteString(w, sampleVerboseJSON)
+	}))
+	defer server.Close()
+
+	transcriber := NewOpenAICompatibleTranscriber(server.URL, "", "", ResponseOptions{WordTimestamps: true})
+	got, err := transcriber.Transcribe(context.Background(), writeTempAudio(t), TranscribeOptions{Language: "de", Translate: true})
+	if err != nil {
+		t.Fatalf("Transcribe() error = %v", err)
+	}
+	if got.Language != "en" {
+		t.Errorf("Language = %q, want en for a translation", got.Language)
+	}
+}
+
+func TestTranscriberUnparseableResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "not json")
+	}))
+	defer server.Close()
+
+	transcriber := NewOpenAICompatibleTranscriber(server.URL, "", "", ResponseOptions{Format: ResponseVerboseJSON})
+	_, err := transcriber.Transcribe(context.Background(), writeTempAudio(t), TranscribeOptions{})
+	if err == nil || IsRetryable(err) {
+		t.Errorf("Transcribe() error = %v, want a permanent parse error", err)
+	}
+}
+
+func TestOpenAICompatibleTranscriberError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	transcriber := NewOpenAICompatibleTranscriber(server.URL, "", "", ResponseOptions{})
+	if _, err := transcriber.Transcribe(context.Background(), writeTempAudio(t), TranscribeOptions{}); err == nil {
+		t.Fatal("Transcribe() expected error for non-200 response")
+	}
+}
+
+func TestResponseOptionsFields(t *testing.T) {
+	fields := ResponseOptions{SpeakerLabels: true}.withDefaults().fields()
+	if got := fields.Get("speaker_labels"); got != "true" {
+		t.Errorf("speaker_labels = %q, want true", got)
+	}
+
+	fields = ResponseOptions{Format: ResponseSRT, SpeakerLabels: true, WordTimestamps: true}.fields()
+	if len(fields) != 1 {
+		t.Errorf("fields() = %v, want only response_format for srt", fields)
+	}
+}
+
+func TestNewTranscriber(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.TranscriberConfig
+		wantErr bool
+	}{
+		{name: "lemonfox", cfg: config.TranscriberConfig{Provider: "lemonfox", APIKey: "key"}},
+		{name: "lemonfox without key", cfg: config.TranscriberConfig{Provider: "lemonfox"}, wantErr: true},
+		{name: "openai", cfg: config.TranscriberConfig{Provider: "openai", APIKey: "key"}},
+		{name: "compatible", cfg: config.TranscriberConfig{Provider: "openai-compatible", BaseURL: "http://localhost:8000/v1"}},
+		{name: "compatible without base url", cfg: config.TranscriberConfig{Provider: "openai-compatible"}, wantErr: true},
+		{name: "unknown", cfg: config.TranscriberConfig{Provider: "nope"}, wantErr: true},
+		{name: "srt format", cfg: config.TranscriberConfig{Provider: "openai", APIKey: "key", ResponseFormat: "srt"}},
+		{name: "lemonfox speaker labels", cfg: config.TranscriberConfig{Provider: "lemonfox", APIKey: "key", SpeakerLabels: true}},
+		{name: "openai speaker labels", cfg: config.TranscriberConfig{Provider: "openai", APIKey: "key", SpeakerLabels: true}, wantErr: true},
+		{name: "speaker labels need verbose_json", cfg: config.TranscriberConfig{Provider: "lemonfox", APIKey: "key", ResponseFormat: "vtt", SpeakerLabels: true}, wantErr: true},
+		{name: "text format has no timings", cfg: config.TranscriberConfig{Provider: "openai", APIKey: "key", ResponseFormat: "text"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTranscriber(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewTranscriber() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsEnglish(t *testing.T) {
+	for _, language := range []string{"en", "English", "en-GB"} {
+		if !isEnglish(language) {
+			t.Errorf("isEnglish(%q) = false", language)
+		}
+	}
+	for _, language := range []string{"", "de", "german", "eng-ish"} {
+		if isEnglish(language) {
+			t.Errorf("isEnglish(%q) = true", language)
+		}
+	}
+}
+
+func TestCanReuse(t *testing.T) {
+	lang := func(language string) *string { return &language }
+	tests := []struct {
+		name     string
+		existing models.Video
+		video    models.Video
+		want     bool
+	}{
+		{"no language asked", models.Video{DetectedLanguage: lang("de")}, models.Video{}, true},
+		{"translation asked", models.Video{DetectedLanguage: lang("de")}, models.Video{Translate: true}, false},
+		{"detected language", models.Video{DetectedLanguage: lang("DE")}, models.Video{RequestedLanguage: lang("de")}, true},
+		{"requested language", models.Video{RequestedLanguage: lang("de")}, models.Video{RequestedLanguage: lang("de")}, true},
+		{"other language", models.Video{DetectedLanguage: lang("en")}, models.Video{RequestedLanguage: lang("de")}, false},
+		{"caption track region", models.Video{DetectedLanguage: lang("de-DE")}, models.Video{RequestedLanguage: lang("de")}, true},
+		{"unknown language", models.Video{}, models.Video{RequestedLanguage: lang("de")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canReuse(&tt.existing, &tt.video); got != tt.want {
+				t.Errorf("canReuse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package transcription
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+// Response formats a Transcriber can request from the provider
+const (
+	ResponseVerboseJSON = "verbose_json"
+	ResponseSRT         = "srt"
+	ResponseVTT         = "vtt"
+)
+
+// parseTranscript reads a provider response in the requested format. A
+// response that can't be parsed won't improve on retry, so errors are
+// permanent.
+func parseTranscript(body string, format string) (*models.Transcript, error) {
+	var (
+		transcript *models.Transcript
+		err        error
+	)
+	switch format {
+	case ResponseVerboseJSON:
+		transcript, err = parseVerboseJSON([]byte(body))
+	case ResponseSRT:
+		var entries []models.SRTEntry
+		entries, err = ParseSRT(body)
+		transcript = models.TranscriptFromEntries(entries)
+	case ResponseVTT:
+		// Some providers escape the newlines of text responses
+		if strings.Contains(body, "\\n") {
+			body = strings.ReplaceAll(body, "\\n", "\n")
+		}
+		var entries []models.SRTEntry
+		entries, err = ParseVTT(body)
+		transcript = models.TranscriptFromEntries(entries)
+	default:
+		err = fmt.Errorf("unsupported response format %q", format)
+	}
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("error parsing %s transcript: %w", format, err))
+	}
+	return transcript, nil
+}
+
+// verboseJSON is Whisper's verbose_json response. Word timings come either at
+// the top level (OpenAI) or inside each segment (Lemonfox, faster-whisper).
+// Lemonfox adds a speaker to each segment when asked for speaker labels.
+type verboseJSON struct {
+	Language string `json:"language"`
+	Segments []struct {
+		Start      float64       `json:"start"`
+		End        float64       `json:"end"`
+		Text       string        `json:"text"`
+		AvgLogprob *float64      `json:"avg_logprob"`
+		Speaker    string        `json:"speaker"`
+		Words      []verboseWord `json:"words"`
+	} `json:"segments"`
+	Words []verboseWord `json:"words"`
+}
+
+type verboseWord struct {
+	Word        string   `json:"word"`
+	Start       float64  `json:"start"`
+	End         float64  `json:"end"`
+	Probability *float64 `json:"probability"`
+}
+
+func parseVerboseJSON(data []byte) (*models.Transcript, error) {
+	var response verboseJSON
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+
+	transcript := &models.Transcript{
+		Language: response.Language,
+		Segments: make([]models.Segment, 0, len(response.Segments)),
+	}
+	for _, s := range response.Segments {
+		segment := models.Segment{
+			Start:   models.SecondsToDuration(s.Start),
+			End:     models.SecondsToDuration(s.End),
+			Text:    strings.TrimSpace(s.Text),
+			Speaker: s.Speaker,
+			Words:   convertWords(s.Words),
+		}
+		if s.AvgLogprob != nil {
+			confidence := math.Exp(*s.AvgLogprob)
+			segment.Confidence = &confidence
+		}
+		transcript.Segments = append(transcript.Segments, segment)
+	}
+
+	if len(response.Words) > 0 {
+		assignWords(transcript.Segments, convertWords(response.Words))
+	}
+	return transcript, nil
+}
+
+func convertWords(words []verboseWord) []models.Word {
+	var converted []models.Word
+	for _, w := range words {
+		text := strings.TrimSpace(w.Word)
+		if text == "" {
+			continue
+		}
+		converted = append(converted, models.Word{
+			Start:      models.SecondsToDuration(w.Start),
+			End:        models.SecondsToDuration(w.End),
+			Text:       text,
+			Confidence: w.Probability,
+		})
+	}
+	return converted
+}
+
+// assignWords gives each segment the words that start before it ends. Words
+// after the last segment stay with it.
+func assignWords(segments []models.Segment, words []models.Word) {
+	if len(segments) == 0 {
+		return
+	}
+	i := 0
+	for _, word := range words {
+		for i < len(segments)-1 && word.Start >= segments[i].End {
+			i++
+		}
+		segments[i].Words = append(segments[i].Words, word)
+	}
+}
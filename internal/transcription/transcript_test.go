@@ -0,0 +1,100 @@
+package transcription
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// sampleVerboseJSON follows OpenAI's shape, with words at the top level
+const sampleVerboseJSON = `{
+  "task": "transcribe",
+  "language": "english",
+  "duration": 4.2,
+  "text": "Hello there. General Kenobi.",
+  "segments": [
+    {"id": 0, "seek": 0, "start": 0.0, "end": 1.5, "text": " Hello there.", "avg_logprob": -0.1, "no_speech_prob": 0.01},
+    {"id": 1, "seek": 0, "start": 1.5, "end": 4.2, "text": " General Kenobi.", "avg_logprob": -0.35, "no_speech_prob": 0.02}
+  ],
+  "words": [
+    {"word": "Hello", "start": 0.0, "end": 0.6},
+    {"word": "there.", "start": 0.6, "end": 1.4},
+    {"word": "General", "start": 1.6, "end": 2.3},
+    {"word": "Kenobi.", "start": 2.3, "end": 4.1}
+  ]
+}`
+
+func TestParseVerboseJSON(t *testing.T) {
+	transcript, err := parseTranscript(sampleVerboseJSON, ResponseVerboseJSON)
+	if err != nil {
+		t.Fatalf("parseTranscript() error = %v", err)
+	}
+
+	first := transcript.Segments[0]
+	if first.Text != "Hello there." || first.End != 1500*time.Millisecond {
+		t.Errorf("first segment = %+v", first)
+	}
+	if first.Confidence == nil || math.Abs(*first.Confidence-math.Exp(-0.1)) > 1e-9 {
+		t.Errorf("confidence = %v, want exp(avg_logprob)", first.Confidence)
+	}
+	if len(first.Words) != 2 || first.Words[1].Text != "there." || first.Words[1].End != 1400*time.Millisecond {
+		t.Errorf("first segment words = %+v", first.Words)
+	}
+}
+
+func TestParseVerboseJSONSegmentWords(t *testing.T) {
+	// Lemonfox and faster-whisper nest words, with probabilities, in segments
+	body := `{"language": "en", "segments": [{"start": 0, "end": 1, "text": "Hi all",
+		"words": [{"word": " Hi", "start": 0, "end": 0.4, "probability": 0.9}, {"word": " all", "start": 0.4, "end": 1, "probability": 0.8}]}]}`
+
+	transcript, err := parseTranscript(body, ResponseVerboseJSON)
+	if err != nil {
+		t.Fatalf("parseTranscript() error = %v", err)
+	}
+	words := transcript.Segments[0].Words
+	if len(words) != 2 || words[0].Text != "Hi" || words[1].Confidence == nil || *words[1].Confidence != 0.8 {
+		t.Errorf("words = %+v", words)
+	}
+	if transcript.Segments[0].Confidence != nil {
+		t.Error("confidence should be nil without avg_logprob")
+	}
+}
+
+func TestParseVerboseJSONSpeakers(t *testing.T) {
+	// Lemonfox labels segments when speaker_labels is set
+	body := `{"language": "en", "segments": [
+		{"start": 0, "end": 1, "text": "Welcome.", "speaker": "SPEAKER_00"},
+		{"start": 1, "end": 2, "text": "Thanks.", "speaker": "SPEAKER_01"}]}`
+
+	transcript, err := parseTranscript(body, ResponseVerboseJSON)
+	if err != nil {
+		t.Fatalf("parseTranscript() error = %v", err)
+	}
+	if got := transcript.Speakers(); len(got) != 2 || got[0] != "SPEAKER_00" || got[1] != "SPEAKER_01" {
+		t.Errorf("Speakers() = %v", got)
+	}
+}
+
+func TestParseSRT(t *testing.T) {
+	content := "\ufeff1\r\n00:00:01,000 --> 00:00:02,500\r\nHello\r\nthere\r\n\r\n" +
+		"2\r\n00:00:03,000 --> 00:00:04,000 X1:10 X2:20\r\nSecond\r\n\r\n" +
+		"00:00:05.000 --> 00:00:06.000\r\nNo number\r\n"
+
+	entries, err := ParseSRT(content)
+	if err != nil {
+		t.Fatalf("ParseSRT() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3: %+v", len(entries), entries)
+	}
+	if entries[0].Text != "Hello there" || entries[0].End != 2500*time.Millisecond {
+		t.Errorf("first entry = %+v", entries[0])
+	}
+	if entries[2].Number != 3 || entries[2].Start != 5*time.Second {
+		t.Errorf("third entry = %+v", entries[2])
+	}
+
+	if _, err := ParseSRT("1\nnot a timing line\ntext"); err == nil {
+		t.Error("ParseSRT() returned no error for a cue without timing")
+	}
+}
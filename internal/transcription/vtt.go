@@ -2,14 +2,14 @@ package transcription
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
 	"jamesfarrell.me/youtube-to-text/internal/storage/models"
 )
 
-// ParseVTT parses WebVTT content into SRT entries
+// ParseVTT parses WebVTT content into SRT entries, joining the lines of each
+// cue with spaces
 func ParseVTT(content string) ([]models.SRTEntry, error) {
 	// Trim any quotes from the content
 	content = strings.Trim(content, "\"")
@@ -19,99 +19,117 @@ func ParseVTT(content string) ([]models.SRTEntry, error) {
 		content = strings.ReplaceAll(content, "\\n", "\n")
 	}
 
-	// Now validate the header with actual newlines
-	if !strings.HasPrefix(content, "WEBVTT\n\n") {
-		return nil, fmt.Errorf("invalid VTT format: missing WEBVTT header")
+	doc, err := ParseWebVTT(content)
+	if err != nil {
+		return nil, err
 	}
-	content = strings.TrimPrefix(content, "WEBVTT\n\n")
 
-	// Split into entries by double newline
 	entries := []models.SRTEntry{}
-	blocks := strings.Split(content, "\n\n")
-	
-	for i, block := range blocks {
-		// Split each block into lines
-		lines := strings.Split(block, "\n")
-		if len(lines) < 2 {
-			continue
-		}
-
-		// First line should be timestamp
-		timestamps := strings.Split(lines[0], " --> ")
-		if len(timestamps) != 2 {
-			continue
-		}
-
-		start, err := parseVTTTimestamp(timestamps[0])
-		if err != nil {
-			return nil, fmt.Errorf("invalid start timestamp: %w", err)
-		}
-
-		end, err := parseVTTTimestamp(timestamps[1])
-		if err != nil {
-			return nil, fmt.Errorf("invalid end timestamp: %w", err)
-		}
-
-		// Remaining lines are the text
-		text := strings.Join(lines[1:], " ")
-
+	for i, cue := range doc.Cues() {
+		speaker, text := parseVoice(strings.Join(strings.Split(cue.Text, "\n"), " "))
 		entries = append(entries, models.SRTEntry{
-			Number: i + 1,
-			Start:  start,
-			End:    end,
-			Text:   text,
+			Number:  i + 1,
+			Start:   cue.Start,
+			End:     cue.End,
+			Text:    text,
+			Speaker: speaker,
 		})
 	}
-
 	return entries, nil
 }
 
-func parseVTTTimestamp(timestamp string) (time.Duration, error) {
-	// Validate format (HH:MM:SS.mmm)
-	if !strings.Contains(timestamp, ".") {
-		return 0, fmt.Errorf("invalid timestamp format: missing milliseconds")
-	}
+// vttEscaper escapes the characters WebVTT cue text and annotations reserve
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
 
-	// Validate timestamp has exactly 2 digits for hours
-	parts := strings.Split(timestamp, ":")
-	if len(parts) != 3 || len(parts[0]) != 2 {
-		return 0, fmt.Errorf("invalid timestamp format: expected HH:MM:SS.mmm")
-	}
+var vttUnescaper = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">")
 
-	// Parse hours
-	hours, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return 0, fmt.Errorf("invalid hours: %w", err)
+// parseVoice splits a leading voice span, "<v Speaker>text" or
+// "<v.loud Speaker>text</v>", into the speaker and the text, unescaping both
+func parseVoice(text string) (string, string) {
+	rest, found := strings.CutPrefix(text, "<v")
+	if !found || rest == "" || (rest[0] != ' ' && rest[0] != '\t' && rest[0] != '.') {
+		return "", vttUnescaper.Replace(text)
+	}
+	tag, body, found := strings.Cut(rest, ">")
+	if !found {
+		return "", vttUnescaper.Replace(text)
 	}
 
-	// Parse minutes
-	minutes, err := strconv.Atoi(parts[1])
-	if err != nil {
-		return 0, fmt.Errorf("invalid minutes: %w", err)
+	// Classes come before the annotation, e.g. <v.loud Speaker>
+	var annotation string
+	if i := strings.IndexAny(tag, " \t"); i >= 0 {
+		annotation = strings.TrimSpace(tag[i:])
 	}
+	body = strings.TrimSpace(strings.ReplaceAll(body, "</v>", ""))
+	return vttUnescaper.Replace(annotation), vttUnescaper.Replace(body)
+}
 
-	// Split seconds and milliseconds
-	secondParts := strings.Split(parts[2], ".")
-	if len(secondParts) != 2 {
-		return 0, fmt.Errorf("invalid seconds format: missing milliseconds")
+// voiceText prefixes text, which must already be escaped, with a voice span
+// for its speaker, if any
+func voiceText(speaker string, text string) string {
+	if speaker == "" {
+		return text
 	}
+	return "<v " + vttEscaper.Replace(speaker) + ">" + text
+}
 
-	// Parse seconds
-	seconds, err := strconv.Atoi(secondParts[0])
-	if err != nil {
-		return 0, fmt.Errorf("invalid seconds: %w", err)
+// writeVTT renders entries as a WebVTT document
+func writeVTT(entries []models.SRTEntry) string {
+	var doc WebVTT
+	for _, entry := range entries {
+		doc.AddCue(VTTCue{Start: entry.Start, End: entry.End, Text: voiceText(entry.Speaker, vttEscaper.Replace(entry.Text))})
 	}
+	return doc.String()
+}
 
-	// Parse milliseconds
-	milliseconds, err := strconv.Atoi(secondParts[1])
-	if err != nil {
-		return 0, fmt.Errorf("invalid milliseconds: %w", err)
+// writeKaraokeVTT renders each segment as a cue whose words carry inline
+// timestamp tags, e.g. "Hello <00:00:01.200>world", so players can highlight
+// words as they are spoken. Segments without word timings are written as
+// plain cues.
+func writeKaraokeVTT(transcript *models.Transcript) string {
+	var doc WebVTT
+	for _, segment := range transcript.Segments {
+		text := vttEscaper.Replace(segment.Text)
+		if len(segment.Words) > 0 {
+			text = karaokeText(segment)
+		}
+		doc.AddCue(VTTCue{Start: segment.Start, End: segment.End, Text: voiceText(segment.Speaker, text)})
 	}
+	return doc.String()
+}
 
-	duration := time.Duration(hours)*time.Hour +
-		time.Duration(minutes)*time.Minute +
-		time.Duration(seconds)*time.Second +
-		time.Duration(milliseconds)*time.Millisecond
+// karaokeText tags every word after the first with its start time. WebVTT
+// requires each tag to fall after the previous one and inside the cue, so
+// words that would break that order are written without a tag.
+func karaokeText(segment models.Segment) string {
+	var b strings.Builder
+	last := segment.Start
+	for _, word := range segment.Words {
+		text := strings.TrimSpace(word.Text)
+		if text == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(" ")
+			if word.Start > last && word.Start < segment.End {
+				fmt.Fprintf(&b, "<%s>", formatTimestamp(word.Start))
+				last = word.Start
+			}
+		}
+		b.WriteString(vttEscaper.Replace(text))
+	}
+	return b.String()
+}
 
-	return duration, nil
-} 
\ No newline at end of file
+// Helper function to format Duration as VTT timestamp
+func formatTimestamp(d time.Duration) string {
+    h := d / time.Hour
+    d -= h * time.Hour
+    m := d / time.Minute
+    d -= m * time.Minute
+    s := d / time.Second
+    d -= s * time.Second
+    ms := d / time.Millisecond
+    
+    return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ms)
+}
@@ -1,8 +1,11 @@
 package transcription
 
 import (
+	"strings"
 	"testing"
 	"time"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
 )
 
 func TestParseVTT(t *testing.T) {
@@ -37,6 +40,12 @@ Second entry`,
 			want:    2,
 			wantErr: false,
 		},
+		{
+			name:    "header text, cue identifiers and notes",
+			content: "WEBVTT - Talk\r\n\r\nNOTE generated\r\n\r\n1\r\n00:01.000 --> 00:04.000 align:start\r\nFirst\r\n\r\n2\r\n00:04.100 --> 00:08.000\r\nSecond\r\n",
+			want:    2,
+			wantErr: false,
+		},
 		{
 			name:    "invalid header",
 			content: "NOT A VTT FILE",
@@ -61,7 +70,7 @@ Second entry`,
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			entries, err := parseVTT(tt.content)
+			entries, err := ParseVTT(tt.content)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseVTT() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -110,6 +119,12 @@ func TestParseVTTTimestamp(t *testing.T) {
 			want:      1*time.Hour + 23*time.Minute + 45*time.Second + 678*time.Millisecond,
 			wantErr:   false,
 		},
+		{
+			name:      "without hours",
+			timestamp: "23:45.678",
+			want:      23*time.Minute + 45*time.Second + 678*time.Millisecond,
+			wantErr:   false,
+		},
 		{
 			name:      "invalid format",
 			timestamp: "1:23:45.678",
@@ -134,4 +149,51 @@ func TestParseVTTTimestamp(t *testing.T) {
 			}
 		})
 	}
-} 
\ No newline at end of file
+}
+
+func TestParseVoice(t *testing.T) {
+	tests := []struct {
+		text        string
+		wantSpeaker string
+		wantText    string
+	}{
+		{"<v Roger Bingham>We are in New York City", "Roger Bingham", "We are in New York City"},
+		{"<v.loud Esme>It's a blue apple tree!</v>", "Esme", "It's a blue apple tree!"},
+		{"<v Tom &amp; Jerry>Hi", "Tom & Jerry", "Hi"},
+		{"<v Ada>a &lt; b &amp; c", "Ada", "a < b & c"},
+		{"x &gt; y", "", "x > y"},
+		{"<verbatim> is not a voice", "", "<verbatim> is not a voice"},
+		{"No voice", "", "No voice"},
+	}
+	for _, tt := range tests {
+		speaker, text := parseVoice(tt.text)
+		if speaker != tt.wantSpeaker || text != tt.wantText {
+			t.Errorf("parseVoice(%q) = %q, %q, want %q, %q", tt.text, speaker, text, tt.wantSpeaker, tt.wantText)
+		}
+	}
+}
+
+func TestWriteVTTRoundTrip(t *testing.T) {
+	entries := []models.SRTEntry{
+		{Number: 1, Start: 0, End: time.Second, Text: "a < b & c"},
+		{Number: 2, Start: time.Second, End: 2 * time.Second, Text: "<b>not a tag</b> & more", Speaker: "Tom & Jerry"},
+	}
+
+	vtt := writeVTT(entries)
+	if strings.Contains(vtt, "a < b") || !strings.Contains(vtt, "a &lt; b &amp; c") {
+		t.Errorf("writeVTT() left cue text unescaped:\n%s", vtt)
+	}
+
+	got, err := ParseVTT(vtt)
+	if err != nil {
+		t.Fatalf("ParseVTT() error = %v", err)
+	}
+	if len(got) != len(entries) {
+		t.Fatalf("ParseVTT() got %d entries, want %d", len(got), len(entries))
+	}
+	for i := range entries {
+		if got[i] != entries[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], entries[i])
+		}
+	}
+}
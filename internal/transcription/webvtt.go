@@ -0,0 +1,280 @@
+package transcription
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebVTT block kinds
+const (
+	BlockCue    = "cue"
+	BlockNote   = "NOTE"
+	BlockStyle  = "STYLE"
+	BlockRegion = "REGION"
+)
+
+// WebVTT is a parsed WebVTT document. Blocks keep their order, so writing a
+// parsed document gives back the same cues, comments and styles.
+type WebVTT struct {
+	// Header is what follows "WEBVTT" up to the first blank line, such as
+	// " - Title" or "\nKind: captions\nLanguage: en"
+	Header string
+	Blocks []VTTBlock
+}
+
+// VTTBlock is a cue, or a NOTE, STYLE or REGION block kept verbatim
+type VTTBlock struct {
+	Kind string
+	Cue  *VTTCue
+	// Text is the whole block, keyword line included, for non-cue blocks
+	Text string
+}
+
+// VTTCue is a WebVTT cue with its optional identifier and settings
+type VTTCue struct {
+	ID       string
+	Start    time.Duration
+	End      time.Duration
+	Settings string // e.g. "align:start position:0%"
+	Text     string // payload lines joined by "\n", markup kept
+}
+
+// Cues returns the document's cues in order
+func (v *WebVTT) Cues() []*VTTCue {
+	var cues []*VTTCue
+	for _, block := range v.Blocks {
+		if block.Kind == BlockCue {
+			cues = append(cues, block.Cue)
+		}
+	}
+	return cues
+}
+
+// AddCue appends a cue to the document
+func (v *WebVTT) AddCue(cue VTTCue) {
+	v.Blocks = append(v.Blocks, VTTBlock{Kind: BlockCue, Cue: &cue})
+}
+
+// ParseWebVTT parses a WebVTT document following the WebVTT spec: a byte
+// order mark and CRLF or CR line endings are accepted, the "WEBVTT" line may
+// carry a description and header lines, cues may have identifiers and
+// settings, and timestamps may leave out the hours. Blocks that are neither
+// cues nor NOTE, STYLE or REGION blocks are dropped, as the spec requires.
+func ParseWebVTT(content string) (*WebVTT, error) {
+	content = strings.TrimPrefix(content, "\ufeff")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\r", "\n")
+
+	lines := strings.Split(content, "\n")
+	if !isVTTSignature(lines[0]) {
+		return nil, fmt.Errorf("invalid VTT format: missing WEBVTT header")
+	}
+
+	// The header runs to the first blank line
+	i := 1
+	for i < len(lines) && lines[i] != "" {
+		i++
+	}
+	doc := &WebVTT{Header: strings.Join(lines[:i], "\n")[len("WEBVTT"):]}
+
+	for i < len(lines) {
+		if lines[i] == "" {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(lines) && lines[i] != "" {
+			i++
+		}
+		blocks, err := parseVTTBlock(lines[start:i], start+1)
+		if err != nil {
+			return nil, err
+		}
+		doc.Blocks = append(doc.Blocks, blocks...)
+	}
+	return doc, nil
+}
+
+func isVTTSignature(line string) bool {
+	if !strings.HasPrefix(line, "WEBVTT") {
+		return false
+	}
+	rest := line[len("WEBVTT"):]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t'
+}
+
+// parseVTTBlock reads the lines between two blank lines. lineNumber is the
+// 1-based line the block starts on, used in errors.
+func parseVTTBlock(lines []string, lineNumber int) ([]VTTBlock, error) {
+	for _, keyword := range []string{BlockNote, BlockStyle, BlockRegion} {
+		if lines[0] == keyword || strings.HasPrefix(lines[0], keyword+" ") || strings.HasPrefix(lines[0], keyword+"\t") {
+			return []VTTBlock{{Kind: keyword, Text: strings.Join(lines, "\n")}}, nil
+		}
+	}
+
+	var blocks []VTTBlock
+	for len(lines) > 0 {
+		var id string
+		if !strings.Contains(lines[0], "-->") {
+			// An identifier must be followed by the timing line
+			if len(lines) < 2 || !strings.Contains(lines[1], "-->") {
+				return blocks, nil
+			}
+			id = lines[0]
+			lines = lines[1:]
+			lineNumber++
+		}
+
+		cue, err := parseCueTiming(lines[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		cue.ID = id
+
+		// The payload ends at the blank line, or at the next timing line when
+		// the blank line is missing
+		end := 1
+		for end < len(lines) && !strings.Contains(lines[end], "-->") {
+			end++
+		}
+		cue.Text = strings.Join(lines[1:end], "\n")
+
+		blocks = append(blocks, VTTBlock{Kind: BlockCue, Cue: cue})
+		lines = lines[end:]
+		lineNumber += end
+	}
+	return blocks, nil
+}
+
+// parseCueTiming reads "start --> end settings"
+func parseCueTiming(line string) (*VTTCue, error) {
+	startText, rest, found := strings.Cut(line, "-->")
+	if !found {
+		return nil, fmt.Errorf("invalid cue timing: %q", line)
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("invalid cue timing: missing end timestamp in %q", line)
+	}
+
+	start, err := parseVTTTimestamp(strings.TrimSpace(startText))
+	if err != nil {
+		return nil, fmt.Errorf("invalid start timestamp: %w", err)
+	}
+	end, err := parseVTTTimestamp(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid end timestamp: %w", err)
+	}
+
+	return &VTTCue{
+		Start:    start,
+		End:      end,
+		Settings: strings.Join(fields[1:], " "),
+	}, nil
+}
+
+// parseVTTTimestamp reads HH:MM:SS.mmm, where the hours have at least two
+// digits, or MM:SS.mmm
+func parseVTTTimestamp(timestamp string) (time.Duration, error) {
+	clock, fraction, found := strings.Cut(timestamp, ".")
+	if !found {
+		return 0, fmt.Errorf("invalid timestamp format: missing milliseconds")
+	}
+	if len(fraction) != 3 || !isDigits(fraction) {
+		return 0, fmt.Errorf("invalid timestamp format: expected three digits of milliseconds in %q", timestamp)
+	}
+
+	parts := strings.Split(clock, ":")
+	var hours string
+	switch {
+	case len(parts) == 3 && len(parts[0]) >= 2:
+		hours, parts = parts[0], parts[1:]
+	case len(parts) == 2:
+		hours = "0"
+	default:
+		return 0, fmt.Errorf("invalid timestamp format: expected HH:MM:SS.mmm or MM:SS.mmm in %q", timestamp)
+	}
+
+	for _, part := range parts {
+		if len(part) != 2 || !isDigits(part) {
+			return 0, fmt.Errorf("invalid timestamp format: expected HH:MM:SS.mmm or MM:SS.mmm in %q", timestamp)
+		}
+	}
+	if !isDigits(hours) {
+		return 0, fmt.Errorf("invalid hours in %q", timestamp)
+	}
+
+	h, _ := strconv.Atoi(hours)
+	m, _ := strconv.Atoi(parts[0])
+	s, _ := strconv.Atoi(parts[1])
+	ms, _ := strconv.Atoi(fraction)
+	if m > 59 || s > 59 {
+		return 0, fmt.Errorf("invalid timestamp: minutes and seconds must be below 60 in %q", timestamp)
+	}
+
+	return time.Duration(h)*time.Hour +
+		time.Duration(m)*time.Minute +
+		time.Duration(s)*time.Second +
+		time.Duration(ms)*time.Millisecond, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// String writes the document back out. Cues use HH:MM:SS.mmm timestamps and
+// every block ends with a blank line.
+func (v *WebVTT) String() string {
+	var b strings.Builder
+	b.WriteString("WEBVTT")
+	b.WriteString(v.Header)
+	b.WriteString("\n\n")
+
+	for _, block := range v.Blocks {
+		if block.Kind != BlockCue {
+			b.WriteString(block.Text)
+			b.WriteString("\n\n")
+			continue
+		}
+
+		cue := block.Cue
+		if cue.ID != "" {
+			b.WriteString(cue.ID)
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s --> %s", formatTimestamp(cue.Start), formatTimestamp(cue.End))
+		if cue.Settings != "" {
+			b.WriteString(" ")
+			b.WriteString(cue.Settings)
+		}
+		b.WriteString("\n")
+		if text := cueText(cue.Text); text != "" {
+			b.WriteString(text)
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// cueText keeps a payload from ending its cue early: blank lines would end
+// the cue and "-->" would start a new one
+func cueText(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			continue
+		}
+		lines = append(lines, strings.ReplaceAll(line, "-->", "->"))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,159 @@
+package transcription
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const fullVTT = `WEBVTT - Example talk
+Kind: captions
+Language: en
+
+STYLE
+::cue {
+  color: yellow;
+}
+
+NOTE This file was edited by hand
+
+intro
+00:00:01.000 --> 00:00:04.000 align:start position:10%
+<v Alice>Hello, this is
+the first cue
+
+00:00:04.100 --> 00:00:08.000
+Second cue with <b>markup</b>
+
+NOTE
+Comments can span
+several lines
+
+01:02:03.456 --> 01:02:05.000 line:0
+Late cue
+
+`
+
+func TestWebVTTRoundTrip(t *testing.T) {
+	doc, err := ParseWebVTT(fullVTT)
+	if err != nil {
+		t.Fatalf("ParseWebVTT() error = %v", err)
+	}
+
+	if got := doc.String(); got != fullVTT {
+		t.Errorf("String() did not round trip:\n%s\nwant\n%s", got, fullVTT)
+	}
+
+	if doc.Header != " - Example talk\nKind: captions\nLanguage: en" {
+		t.Errorf("Header = %q", doc.Header)
+	}
+
+	kinds := []string{}
+	for _, block := range doc.Blocks {
+		kinds = append(kinds, block.Kind)
+	}
+	if got := strings.Join(kinds, ","); got != "STYLE,NOTE,cue,cue,NOTE,cue" {
+		t.Errorf("block kinds = %s", got)
+	}
+
+	cues := doc.Cues()
+	if len(cues) != 3 {
+		t.Fatalf("got %d cues, want 3", len(cues))
+	}
+	first := cues[0]
+	if first.ID != "intro" || first.Settings != "align:start position:10%" || first.Text != "<v Alice>Hello, this is\nthe first cue" {
+		t.Errorf("first cue = %+v", first)
+	}
+	if want := time.Hour + 2*time.Minute + 3*time.Second + 456*time.Millisecond; cues[2].Start != want {
+		t.Errorf("late cue start = %v, want %v", cues[2].Start, want)
+	}
+}
+
+func TestParseWebVTTLeniency(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantCues []VTTCue
+	}{
+		{
+			name:     "CRLF line endings and BOM",
+			content:  "\ufeffWEBVTT\r\n\r\n00:01.000 --> 00:02.500\r\nHello\r\n\r\n",
+			wantCues: []VTTCue{{Start: time.Second, End: 2500 * time.Millisecond, Text: "Hello"}},
+		},
+		{
+			name:     "minutes and seconds only",
+			content:  "WEBVTT\n\n01:05.000 --> 01:06.000\nShort form",
+			wantCues: []VTTCue{{Start: 65 * time.Second, End: 66 * time.Second, Text: "Short form"}},
+		},
+		{
+			name:    "missing blank line between cues",
+			content: "WEBVTT\n\n00:00.000 --> 00:01.000\nOne\n00:01.000 --> 00:02.000\nTwo\n",
+			wantCues: []VTTCue{
+				{Start: 0, End: time.Second, Text: "One"},
+				{Start: time.Second, End: 2 * time.Second, Text: "Two"},
+			},
+		},
+		{
+			name:     "block without timing is dropped",
+			content:  "WEBVTT\n\njust some text\n\n00:00.000 --> 00:01.000\nKept",
+			wantCues: []VTTCue{{Start: 0, End: time.Second, Text: "Kept"}},
+		},
+		{
+			name:     "empty payload",
+			content:  "WEBVTT\n\n00:00.000 --> 00:01.000\n\n",
+			wantCues: []VTTCue{{Start: 0, End: time.Second}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseWebVTT(tt.content)
+			if err != nil {
+				t.Fatalf("ParseWebVTT() error = %v", err)
+			}
+			cues := doc.Cues()
+			if len(cues) != len(tt.wantCues) {
+				t.Fatalf("got %d cues, want %d", len(cues), len(tt.wantCues))
+			}
+			for i, want := range tt.wantCues {
+				if *cues[i] != want {
+					t.Errorf("cue %d = %+v, want %+v", i, *cues[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseWebVTTErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "missing signature", content: "00:00.000 --> 00:01.000\nText"},
+		{name: "text glued to signature", content: "WEBVTTX\n\n"},
+		{name: "bad end timestamp", content: "WEBVTT\n\n00:00.000 --> 0:01.0\nText"},
+		{name: "seconds out of range", content: "WEBVTT\n\n00:00:75.000 --> 00:01:00.000\nText"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseWebVTT(tt.content); err == nil {
+				t.Error("ParseWebVTT() returned no error")
+			}
+		})
+	}
+}
+
+func TestWebVTTWriterKeepsCuesIntact(t *testing.T) {
+	var doc WebVTT
+	doc.AddCue(VTTCue{Start: 0, End: time.Second, Text: "first\n\nsecond --> third"})
+
+	parsed, err := ParseWebVTT(doc.String())
+	if err != nil {
+		t.Fatalf("ParseWebVTT() error = %v", err)
+	}
+	cues := parsed.Cues()
+	if len(cues) != 1 || cues[0].Text != "first\nsecond -> third" {
+		t.Errorf("cues = %+v, want one cue with the blank line and arrow removed", cues)
+	}
+}
@@ -0,0 +1,190 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"jamesfarrell.me/youtube-to-text/internal/storage/models"
+)
+
+const (
+	// pollInterval is how often the worker sweeps for unqueued videos and
+	// checks the queue when no notification arrives
+	pollInterval = time.Minute
+	// jobLease is how long a running job may go without a heartbeat before
+	// another worker treats it as orphaned
+	jobLease          = 5 * time.Minute
+	heartbeatInterval = time.Minute
+)
+
+func newWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "worker"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// stageLimiter bounds how many videos can be in a pipeline stage at once,
+// keeping the pool within yt-dlp and provider rate limits
+type stageLimiter chan struct{}
+
+func newStageLimiter(n int) stageLimiter {
+	if n < 1 {
+		n = 1
+	}
+	return make(stageLimiter, n)
+}
+
+func (l stageLimiter) acquire(ctx context.Context) error {
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l stageLimiter) release() {
+	<-l
+}
+
+// runWorkers starts the worker pool and blocks until ctx is cancelled.
+// Each worker drains the queue, then sleeps until woken or the poll interval passes.
+func (s *Service) runWorkers(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		workerID := fmt.Sprintf("%s-%d", s.workerID, i)
+		go func() {
+			defer wg.Done()
+			for {
+				s.drainQueue(ctx, workerID)
+				select {
+				case <-ctx.Done():
+					return
+				case <-s.wake:
+				case <-time.After(pollInterval):
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// wakeWorkers nudges idle workers without blocking when they are all busy
+func (s *Service) wakeWorkers() {
+	for i := 0; i < s.workers; i++ {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+			return
+		}
+	}
+}
+
+// sweep queues every pending or orphaned video so nothing depends on a
+// notification having been delivered
+func (s *Service) sweep(ctx context.Context) {
+	if n, err := s.jobRepo.RequeueStale(ctx, jobLease); err != nil {
+		fmt.Printf("Error requeueing stale jobs: %v\n", err)
+	} else if n > 0 {
+		fmt.Printf("Requeued %d orphaned jobs\n", n)
+	}
+
+	if n, err := s.jobRepo.EnqueueUnfinished(ctx); err != nil {
+		fmt.Printf("Error enqueueing unfinished videos: %v\n", err)
+	} else if n > 0 {
+		fmt.Printf("Enqueued %d unfinished videos\n", n)
+	}
+}
+
+// drainQueue processes jobs until none are runnable
+func (s *Service) drainQueue(ctx context.Context, workerID string) {
+	for ctx.Err() == nil {
+		job, err := s.jobRepo.Claim(ctx, workerID)
+		if err != nil {
+			fmt.Printf("Error claiming job: %v\n", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+
+		fmt.Printf("[%s] Claimed job %d for video %s (attempt %d)\n", workerID, job.ID, job.VideoID, job.Attempts)
+		if err := s.processJob(ctx, workerID, job); err != nil {
+			fmt.Printf("[%s] Error processing video %s: %v\n", workerID, job.VideoID, err)
+			s.handleFailure(ctx, job, err)
+			continue
+		}
+
+		if err := s.jobRepo.Complete(ctx, job.ID); err != nil {
+			fmt.Printf("Error marking job %d done: %v\n", job.ID, err)
+			continue
+		}
+		fmt.Printf("[%s] Successfully processed video %s\n", workerID, job.VideoID)
+	}
+}
+
+func (s *Service) processJob(ctx context.Context, workerID string, job *models.Job) error {
+	video, err := s.transcriptionRepo.GetVideo(job.VideoID)
+	if err != nil {
+		return fmt.Errorf("failed to load video: %w", err)
+	}
+
+	if err := s.transcriptionRepo.StartVideoAttempt(video.ID); err != nil {
+		return fmt.Errorf("failed to update status to processing: %w", err)
+	}
+
+	stop := s.keepAlive(ctx, workerID, job.ID)
+	defer stop()
+
+	return s.processVideo(ctx, video)
+}
+
+// handleFailure reschedules the job with backoff when the error is retryable
+// and attempts remain, otherwise it marks the job and video as failed
+func (s *Service) handleFailure(ctx context.Context, job *models.Job, err error) {
+	policy := s.retry.Job
+	if IsRetryable(err) && job.Attempts < policy.MaxAttempts {
+		delay := policy.Backoff(job.Attempts)
+		fmt.Printf("Retrying video %s in %v (attempt %d/%d)\n", job.VideoID, delay, job.Attempts, policy.MaxAttempts)
+		if err := s.jobRepo.Retry(ctx, job.ID, delay, err.Error()); err != nil {
+			fmt.Printf("Error rescheduling job %d: %v\n", job.ID, err)
+		}
+		if err := s.transcriptionRepo.RecordVideoFailure(job.VideoID, "pending", err.Error()); err != nil {
+			fmt.Printf("Error recording failure for video %s: %v\n", job.VideoID, err)
+		}
+		return
+	}
+
+	if err := s.jobRepo.Fail(ctx, job.ID, err.Error()); err != nil {
+		fmt.Printf("Error marking job %d failed: %v\n", job.ID, err)
+	}
+	if err := s.transcriptionRepo.RecordVideoFailure(job.VideoID, "failed", err.Error()); err != nil {
+		fmt.Printf("Error recording failure for video %s: %v\n", job.VideoID, err)
+	}
+}
+
+// keepAlive renews the job lease until the returned func is called
+func (s *Service) keepAlive(ctx context.Context, workerID string, jobID int64) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.jobRepo.Heartbeat(ctx, jobID, workerID); err != nil {
+					fmt.Printf("Heartbeat error for job %d: %v\n", jobID, err)
+				}
+			}
+		}
+	}()
+	return cancel
+}
@@ -2,11 +2,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/joho/godotenv"
+	"jamesfarrell.me/youtube-to-text/internal/transcription"
 )
 
 func main() {
@@ -33,11 +35,17 @@ func runOneOff() error {
 		return fmt.Errorf("download error: %v", err)
 	}
 
-	transcription, err := sendAudioToLemonfox(outputPath, apiKey)
+	transcriber := transcription.NewLemonfoxTranscriber(apiKey, transcription.ResponseOptions{Format: transcription.ResponseVTT})
+	transcript, err := transcriber.Transcribe(context.Background(), outputPath, transcription.TranscribeOptions{})
 	if err != nil {
 		return fmt.Errorf("transcription error: %v", err)
 	}
+
+	result, err := transcription.Export(transcript, transcription.FormatVTT, transcription.ExportOptions{})
+	if err != nil {
+		return fmt.Errorf("export error: %v", err)
+	}
 	
-	fmt.Printf("Transcription: %s\n", transcription)
+	fmt.Printf("Transcription: %s\n", result)
 	return nil
 } 
\ No newline at end of file
@@ -13,23 +13,120 @@ CREATE TABLE IF NOT EXISTS "Video" (
     "userId" TEXT NOT NULL
 );
 
+-- Processing attempts and the most recent failure, kept for on-call
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS "lastError" TEXT;
+
+-- Where the transcript came from: transcription, manual_captions or auto_captions
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS "transcriptSource" TEXT;
+-- The provider-neutral transcript (segments, words, confidence) that
+-- transcription is rendered from
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS "transcriptData" JSONB;
+-- youtube; rss for podcast episodes, whose "videoUrl" is the enclosure URL; or
+-- upload for recordings stored in UPLOAD_DIR, whose "videoUrl" is upload://<file>
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS "sourceType" TEXT NOT NULL DEFAULT 'youtube';
+-- The spoken language asked for when the video was added (NULL to detect it),
+-- the language the transcript came back in, and whether to also store an
+-- English translation in "translationData"
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS "requestedLanguage" TEXT;
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS "detectedLanguage" TEXT;
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS translate BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS "translationData" JSONB;
+-- Names for diarization labels, e.g. {"SPEAKER_00": "Ada"}
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS "speakerNames" JSONB;
+
+-- Video metadata from yt-dlp -J
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS "durationSeconds" DOUBLE PRECISION;
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS "channelName" TEXT;
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS "channelId" TEXT;
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS "uploadDate" DATE;
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS description TEXT;
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS "thumbnailUrl" TEXT;
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS tags TEXT[];
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS chapters JSONB;
+ALTER TABLE "Video" ADD COLUMN IF NOT EXISTS language TEXT;
+
+CREATE INDEX IF NOT EXISTS "Video_channelId_idx" ON "Video" ("channelId");
+CREATE INDEX IF NOT EXISTS "Video_uploadDate_idx" ON "Video" ("uploadDate");
+CREATE INDEX IF NOT EXISTS "Video_userId_idx" ON "Video" ("userId");
+
+CREATE INDEX IF NOT EXISTS "Video_slug_idx" ON "Video" (slug);
+
+-- Playlists and channels imported as a group of videos
+CREATE TABLE IF NOT EXISTS "Collection" (
+    id TEXT PRIMARY KEY,
+    "sourceUrl" TEXT NOT NULL UNIQUE,
+    title TEXT,
+    kind TEXT NOT NULL,
+    "userId" TEXT NOT NULL,
+    "createdAt" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS "CollectionVideo" (
+    collection_id TEXT NOT NULL REFERENCES "Collection"(id) ON DELETE CASCADE,
+    video_id TEXT NOT NULL REFERENCES "Video"(id) ON DELETE CASCADE,
+    position INTEGER,
+    PRIMARY KEY (collection_id, video_id)
+);
+
+CREATE TABLE IF NOT EXISTS "Subscription" (
+    id TEXT PRIMARY KEY,
+    "sourceUrl" TEXT NOT NULL UNIQUE,
+    kind TEXT NOT NULL,
+    title TEXT,
+    "isSearchable" BOOLEAN NOT NULL DEFAULT false,
+    "pollIntervalMinutes" INTEGER NOT NULL DEFAULT 60 CHECK ("pollIntervalMinutes" > 0),
+    active BOOLEAN NOT NULL DEFAULT true,
+    "lastSeenVideoId" TEXT,
+    "lastPolledAt" TIMESTAMP WITH TIME ZONE,
+    "userId" TEXT NOT NULL,
+    "createdAt" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
 CREATE TABLE IF NOT EXISTS "VideoChunk" (
     id SERIAL PRIMARY KEY,
     video_id TEXT REFERENCES "Video"(id),
     chunk_text TEXT NOT NULL,
-    chunk_embedding vector(1536),
+    chunk_embedding vector,
     chunk_start_time INTERVAL,
     chunk_end_time INTERVAL,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Timed words in the chunk, so search hits can point at the matching word
+ALTER TABLE "VideoChunk" ADD COLUMN IF NOT EXISTS words JSONB;
+-- Diarization labels of who speaks in the chunk
+ALTER TABLE "VideoChunk" ADD COLUMN IF NOT EXISTS speakers TEXT[];
+-- The model that produced the vector and its size. Search only compares
+-- vectors from the model the query was embedded with.
+ALTER TABLE "VideoChunk" ADD COLUMN IF NOT EXISTS embedding_model TEXT;
+ALTER TABLE "VideoChunk" ADD COLUMN IF NOT EXISTS embedding_dimensions INTEGER;
+-- Chunks written before models were recorded all came from ada-002
+UPDATE "VideoChunk" SET embedding_model = 'text-embedding-ada-002', embedding_dimensions = 1536
+WHERE embedding_model IS NULL AND chunk_embedding IS NOT NULL;
+CREATE INDEX IF NOT EXISTS "VideoChunk_video_model_idx" ON "VideoChunk" (video_id, embedding_model);
+-- Full-text index for keyword search. The simple configuration skips stemming
+-- and stop words, so names, product codes and acronyms match as typed in any
+-- language; semantic search covers other word forms.
+ALTER TABLE "VideoChunk" ADD COLUMN IF NOT EXISTS chunk_tsv tsvector
+    GENERATED ALWAYS AS (to_tsvector('simple', chunk_text)) STORED;
+CREATE INDEX IF NOT EXISTS "VideoChunk_chunk_tsv_idx" ON "VideoChunk" USING GIN (chunk_tsv);
+
 -- Drop existing index if it exists
 DROP INDEX IF EXISTS "VideoChunk_chunk_embedding_idx";
 
--- Recreate the index with explicit name
+-- Vectors of any size can sit side by side while a new model is indexed
+ALTER TABLE "VideoChunk" ALTER COLUMN chunk_embedding TYPE vector;
+
+-- Recreate the index with explicit name. ivfflat needs a fixed size, so it
+-- covers the 1536-dimension vectors; add a matching index when cutting over
+-- to a model of another size.
 CREATE INDEX "VideoChunk_chunk_embedding_idx" ON "VideoChunk" 
-USING ivfflat (chunk_embedding vector_cosine_ops)
-WITH (lists = 100);
+USING ivfflat ((chunk_embedding::vector(1536)) vector_cosine_ops)
+WITH (lists = 100)
+WHERE embedding_dimensions = 1536;
 
 CREATE OR REPLACE FUNCTION update_updated_at_column()
 RETURNS TRIGGER AS $$
@@ -44,11 +141,31 @@ CREATE TRIGGER update_video_updated_at
     FOR EACH ROW
     EXECUTE FUNCTION update_updated_at_column();
 
--- Add notification trigger for new videos
+-- Durable work queue for the transcription worker
+CREATE TABLE IF NOT EXISTS "TranscriptionJob" (
+    id SERIAL PRIMARY KEY,
+    video_id TEXT NOT NULL UNIQUE REFERENCES "Video"(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'queued',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    locked_by TEXT,
+    locked_at TIMESTAMP WITH TIME ZONE,
+    run_after TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS "TranscriptionJob_queued_idx" ON "TranscriptionJob" (run_after)
+WHERE status = 'queued';
+
+-- Queue a job for every new video. The notification is only a wake-up hint
+-- for listening workers, the job row is what gets processed.
 CREATE OR REPLACE FUNCTION notify_new_video()
   RETURNS trigger AS $$
 BEGIN
-  PERFORM pg_notify('new_video', row_to_json(NEW)::text);
+  INSERT INTO "TranscriptionJob" (video_id) VALUES (NEW.id)
+  ON CONFLICT (video_id) DO NOTHING;
+  PERFORM pg_notify('new_video', NEW.id);
   RETURN NEW;
 END;
 $$ LANGUAGE plpgsql;